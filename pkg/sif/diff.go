@@ -0,0 +1,485 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	"cmp"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// Diff describes the differences between two OCI SIF files. It is suitable for encoding as JSON.
+type Diff struct {
+	// From is the digest of the RootIndex of the original SIF.
+	From v1.Hash `json:"from"`
+	// To is the digest of the RootIndex of the updated SIF.
+	To v1.Hash `json:"to"`
+	// Refs holds the references that were added, removed, or moved to another manifest.
+	Refs []RefDiff `json:"refs,omitempty"`
+	// Images holds the images that were added, removed or changed.
+	Images []ImageDiff `json:"images,omitempty"`
+	// Blobs describes the blobs that were added, removed or shared.
+	Blobs BlobDiff `json:"blobs"`
+}
+
+// RefDiff describes a change to an `org.opencontainers.image.ref.name` annotation in the
+// RootIndex.
+type RefDiff struct {
+	// Name is the value of the reference.
+	Name string `json:"name"`
+	// From is the digest of the manifest the reference referred to, or nil if it was added.
+	From *v1.Hash `json:"from,omitempty"`
+	// To is the digest of the manifest the reference refers to, or nil if it was removed.
+	To *v1.Hash `json:"to,omitempty"`
+}
+
+// ImageDiff describes an image that was added, removed or changed. Images are identified by the
+// reference of the RootIndex entry that holds them, and by platform if they are held in an index.
+// Images without a reference, and images that share a reference and platform with another image
+// in the same SIF, are also identified by digest, so can only be added or removed.
+type ImageDiff struct {
+	// Ref is the reference that identifies the image, if any.
+	Ref string `json:"ref,omitempty"`
+	// Platform is the platform of the image, if known.
+	Platform string `json:"platform,omitempty"`
+	// Digest is the digest of the image manifest, if required to identify the image.
+	Digest *v1.Hash `json:"digest,omitempty"`
+	// From is the digest of the original image manifest, or nil if the image was added.
+	From *v1.Hash `json:"from,omitempty"`
+	// To is the digest of the updated image manifest, or nil if the image was removed.
+	To *v1.Hash `json:"to,omitempty"`
+	// Config holds the fields of the image config that changed.
+	Config []FieldDiff `json:"config,omitempty"`
+	// SharedLayers holds the digests of layers present in both images.
+	SharedLayers []v1.Hash `json:"sharedLayers,omitempty"`
+	// AddedLayers holds the digests of layers present only in the updated image.
+	AddedLayers []v1.Hash `json:"addedLayers,omitempty"`
+	// RemovedLayers holds the digests of layers present only in the original image.
+	RemovedLayers []v1.Hash `json:"removedLayers,omitempty"`
+}
+
+// FieldDiff describes a field of a JSON document that changed.
+type FieldDiff struct {
+	// Path is the path of the field, with object keys separated by ".".
+	Path string `json:"path"`
+	// From is the original value of the field, or nil if it was added.
+	From json.RawMessage `json:"from,omitempty"`
+	// To is the updated value of the field, or nil if it was removed.
+	To json.RawMessage `json:"to,omitempty"`
+}
+
+// BlobDiff describes the blobs that were added, removed or shared.
+type BlobDiff struct {
+	// Added holds the digests of blobs present only in the updated SIF.
+	Added []v1.Hash `json:"added,omitempty"`
+	// AddedBytes is the total size of the added blobs.
+	AddedBytes int64 `json:"addedBytes"`
+	// Removed holds the digests of blobs present only in the original SIF.
+	Removed []v1.Hash `json:"removed,omitempty"`
+	// RemovedBytes is the total size of the removed blobs.
+	RemovedBytes int64 `json:"removedBytes"`
+	// Shared is the number of blobs present in both SIFs.
+	Shared int `json:"shared"`
+	// SharedBytes is the total size of the shared blobs.
+	SharedBytes int64 `json:"sharedBytes"`
+}
+
+// Diff compares f, as the original SIF, with to, as the updated SIF. The RootIndex, manifests and
+// configs of images, and the set of blobs in each SIF are compared. Neither SIF is modified.
+func (f *OCIFileImage) Diff(to *OCIFileImage) (*Diff, error) {
+	var d Diff
+	var err error
+
+	if d.From, err = rootIndexDigest(f); err != nil {
+		return nil, err
+	}
+	if d.To, err = rootIndexDigest(to); err != nil {
+		return nil, err
+	}
+
+	if d.Refs, err = diffRefs(f, to); err != nil {
+		return nil, err
+	}
+
+	if d.Images, err = diffImages(f, to); err != nil {
+		return nil, err
+	}
+
+	if d.Blobs, err = diffBlobs(f, to); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// rootIndexDigest returns the digest of the RootIndex of f.
+func rootIndexDigest(f *OCIFileImage) (v1.Hash, error) {
+	ri, err := f.RootIndex()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	return ri.Digest()
+}
+
+// diffRefs returns the references that differ between from and to, sorted by name.
+func diffRefs(from, to *OCIFileImage) ([]RefDiff, error) {
+	fromTags, err := from.Tags()
+	if err != nil {
+		return nil, err
+	}
+	toTags, err := to.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	var rds []RefDiff
+	for _, name := range slices.Sorted(maps.Keys(mergeKeys(fromTags, toTags))) {
+		fh, inFrom := fromTags[name]
+		th, inTo := toTags[name]
+		if inFrom && inTo && fh == th {
+			continue
+		}
+
+		rd := RefDiff{Name: name}
+		if inFrom {
+			rd.From = &fh
+		}
+		if inTo {
+			rd.To = &th
+		}
+		rds = append(rds, rd)
+	}
+
+	return rds, nil
+}
+
+// mergeKeys returns a set holding the keys of a and b.
+func mergeKeys[K comparable, V any](a, b map[K]V) map[K]bool {
+	keys := make(map[K]bool, len(a)+len(b))
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
+
+// imageKey identifies an image when comparing SIFs.
+type imageKey struct {
+	ref      string
+	platform string
+	digest   v1.Hash // digest is only set if ref is empty, or ref and platform are not unique.
+}
+
+// images returns the images held in f, including those held in indexes, mapped by key.
+func images(f *OCIFileImage) (map[imageKey]v1.Image, error) {
+	ri, err := f.RootIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[imageKey]map[v1.Hash]v1.Image)
+	if err := walkImages(ri, "", found); err != nil {
+		return nil, err
+	}
+
+	// If more than one image has the same key, include the digest in the key of each.
+	imgs := make(map[imageKey]v1.Image)
+	for key, byDigest := range found {
+		for h, img := range byDigest {
+			if len(byDigest) > 1 {
+				key.digest = h
+			}
+			imgs[key] = img
+		}
+	}
+	return imgs, nil
+}
+
+// walkImages adds the images held in ii, directly or via a nested index, to imgs, grouped by key
+// and digest. If ref is empty, the reference of each image is taken from the annotations of its
+// entry in ii.
+func walkImages(ii v1.ImageIndex, ref string, imgs map[imageKey]map[v1.Hash]v1.Image) error {
+	im, err := ii.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	for _, desc := range im.Manifests {
+		r := ref
+		if r == "" {
+			r = desc.Annotations[imagespec.AnnotationRefName]
+		}
+
+		switch {
+		case desc.MediaType.IsIndex():
+			child, err := ii.ImageIndex(desc.Digest)
+			if err != nil {
+				return err
+			}
+			if err := walkImages(child, r, imgs); err != nil {
+				return err
+			}
+
+		case desc.MediaType.IsImage():
+			img, err := ii.Image(desc.Digest)
+			if err != nil {
+				return err
+			}
+
+			key := imageKey{ref: r}
+			if desc.Platform != nil {
+				key.platform = desc.Platform.String()
+			}
+			if r == "" {
+				key.digest = desc.Digest
+			}
+			if imgs[key] == nil {
+				imgs[key] = make(map[v1.Hash]v1.Image)
+			}
+			imgs[key][desc.Digest] = img
+		}
+	}
+
+	return nil
+}
+
+// diffImages returns the images that differ between from and to, sorted by reference, platform
+// and digest.
+func diffImages(from, to *OCIFileImage) ([]ImageDiff, error) {
+	fromImages, err := images(from)
+	if err != nil {
+		return nil, err
+	}
+	toImages, err := images(to)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := slices.SortedFunc(maps.Keys(mergeKeys(fromImages, toImages)), func(a, b imageKey) int {
+		return cmp.Or(
+			cmp.Compare(a.ref, b.ref),
+			cmp.Compare(a.platform, b.platform),
+			cmp.Compare(a.digest.String(), b.digest.String()),
+		)
+	})
+
+	var ids []ImageDiff
+	for _, key := range keys {
+		fi, inFrom := fromImages[key]
+		ti, inTo := toImages[key]
+
+		id := ImageDiff{
+			Ref:      key.ref,
+			Platform: key.platform,
+		}
+		if key.digest != (v1.Hash{}) {
+			id.Digest = &key.digest
+		}
+
+		if inFrom {
+			h, err := fi.Digest()
+			if err != nil {
+				return nil, err
+			}
+			id.From = &h
+		}
+		if inTo {
+			h, err := ti.Digest()
+			if err != nil {
+				return nil, err
+			}
+			id.To = &h
+		}
+
+		if inFrom && inTo {
+			if *id.From == *id.To {
+				continue
+			}
+			if err := diffImage(fi, ti, &id); err != nil {
+				return nil, err
+			}
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// diffImage records the differences in the configs and layers of from and to in id.
+func diffImage(from, to v1.Image, id *ImageDiff) error {
+	fc, err := from.RawConfigFile()
+	if err != nil {
+		return err
+	}
+	tc, err := to.RawConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if id.Config, err = diffJSON(fc, tc); err != nil {
+		return err
+	}
+
+	fm, err := from.Manifest()
+	if err != nil {
+		return err
+	}
+	tm, err := to.Manifest()
+	if err != nil {
+		return err
+	}
+
+	inFrom := make(map[v1.Hash]bool)
+	for _, l := range fm.Layers {
+		inFrom[l.Digest] = true
+	}
+	inTo := make(map[v1.Hash]bool)
+	for _, l := range tm.Layers {
+		inTo[l.Digest] = true
+	}
+
+	for _, l := range tm.Layers {
+		if inFrom[l.Digest] {
+			id.SharedLayers = append(id.SharedLayers, l.Digest)
+		} else {
+			id.AddedLayers = append(id.AddedLayers, l.Digest)
+		}
+	}
+	for _, l := range fm.Layers {
+		if !inTo[l.Digest] {
+			id.RemovedLayers = append(id.RemovedLayers, l.Digest)
+		}
+	}
+
+	return nil
+}
+
+// diffJSON returns the fields that differ between the JSON documents a and b, sorted by path.
+// Objects are compared field by field. Any other values, including arrays, are compared as a
+// whole.
+func diffJSON(a, b []byte) ([]FieldDiff, error) {
+	var av, bv any
+	if err := json.Unmarshal(a, &av); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &bv); err != nil {
+		return nil, err
+	}
+
+	var fds []FieldDiff
+	if err := diffValues("", av, bv, &fds); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(fds, func(a, b FieldDiff) int { return cmp.Compare(a.Path, b.Path) })
+	return fds, nil
+}
+
+// diffValues appends a FieldDiff to fds for each difference between a and b, which are found at
+// path. A nil value indicates that the field is not present.
+func diffValues(path string, a, b any, fds *[]FieldDiff) error {
+	am, aIsObject := a.(map[string]any)
+	bm, bIsObject := b.(map[string]any)
+
+	if aIsObject && bIsObject {
+		for k := range mergeKeys(am, bm) {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+
+			if err := diffValues(p, am[k], bm[k], fds); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+
+	fd := FieldDiff{Path: path}
+	if a != nil {
+		raw, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		fd.From = raw
+	}
+	if b != nil {
+		raw, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		fd.To = raw
+	}
+	*fds = append(*fds, fd)
+
+	return nil
+}
+
+// blobSizes returns the OCI.Blob objects in f, mapped by digest to their sizes.
+func blobSizes(f *OCIFileImage) (map[v1.Hash]int64, error) {
+	ds, err := f.sif.GetDescriptors(sif.WithDataType(sif.DataOCIBlob))
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[v1.Hash]int64, len(ds))
+	for _, d := range ds {
+		h, err := d.OCIBlobDigest()
+		if err != nil {
+			return nil, err
+		}
+		sizes[h] = d.Size()
+	}
+	return sizes, nil
+}
+
+// diffBlobs compares the blobs held in from and to.
+func diffBlobs(from, to *OCIFileImage) (BlobDiff, error) {
+	fromBlobs, err := blobSizes(from)
+	if err != nil {
+		return BlobDiff{}, err
+	}
+	toBlobs, err := blobSizes(to)
+	if err != nil {
+		return BlobDiff{}, err
+	}
+
+	var bd BlobDiff
+
+	hs := slices.SortedFunc(maps.Keys(mergeKeys(fromBlobs, toBlobs)), func(a, b v1.Hash) int {
+		return cmp.Compare(a.String(), b.String())
+	})
+	for _, h := range hs {
+		fs, inFrom := fromBlobs[h]
+		ts, inTo := toBlobs[h]
+
+		switch {
+		case inFrom && inTo:
+			bd.Shared++
+			bd.SharedBytes += ts
+		case inTo:
+			bd.Added = append(bd.Added, h)
+			bd.AddedBytes += ts
+		default:
+			bd.Removed = append(bd.Removed, h)
+			bd.RemovedBytes += fs
+		}
+	}
+
+	return bd, nil
+}
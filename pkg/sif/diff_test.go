@@ -0,0 +1,272 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"cmp"
+	"encoding/json"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	ggcrmutate "github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sylabs/oci-tools/pkg/sif"
+)
+
+// diffSIF returns an OCIFileImage holding the specified images, each appended with the
+// corresponding reference. A nil reference is not set.
+func diffSIF(t *testing.T, imgs []v1.Image, refs []name.Reference) *sif.OCIFileImage {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "image.sif")
+	if err := sif.Write(path, empty.Index); err != nil {
+		t.Fatal(err)
+	}
+
+	ofi, err := sif.LoadFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ofi.Unload() })
+
+	for i, img := range imgs {
+		var opts []sif.AppendOpt
+		if refs[i] != nil {
+			opts = append(opts, sif.OptAppendReference(refs[i]))
+		}
+		if err := ofi.AppendImage(img, opts...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return ofi
+}
+
+// nestedSIF returns an OCIFileImage holding an index, with reference ref, that holds imgs.
+func nestedSIF(t *testing.T, ref name.Reference, imgs ...v1.Image) *sif.OCIFileImage {
+	t.Helper()
+
+	var ii v1.ImageIndex = empty.Index
+	for _, img := range imgs {
+		ii = ggcrmutate.AppendManifests(ii, ggcrmutate.IndexAddendum{Add: img})
+	}
+	ii = ggcrmutate.AppendManifests(empty.Index, ggcrmutate.IndexAddendum{
+		Add: ii,
+		Descriptor: v1.Descriptor{
+			Annotations: map[string]string{imagespec.AnnotationRefName: ref.Name()},
+		},
+	})
+
+	path := filepath.Join(t.TempDir(), "image.sif")
+	if err := sif.Write(path, ii); err != nil {
+		t.Fatal(err)
+	}
+
+	ofi, err := sif.LoadFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ofi.Unload() })
+
+	return ofi
+}
+
+// imageBlobs returns the digests of the manifest and config of img.
+func imageBlobs(t *testing.T, img v1.Image) (v1.Hash, v1.Hash) {
+	t.Helper()
+
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := img.ConfigName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, c
+}
+
+func Test_OCIFileImage_Diff(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+	img1, err := random.Image(64, 2, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img2, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img3, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Change the config of img1, and add a layer.
+	cfg, err := img1.ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg = cfg.DeepCopy()
+	cfg.Config.Env = []string{"FOO=bar"}
+	changed, err := ggcrmutate.ConfigFile(img1, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := random.Layer(64, types.DockerLayer, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err = ggcrmutate.AppendLayers(changed, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refA := name.MustParseReference("myimage:v1", name.WithDefaultRegistry(""))
+	refB := name.MustParseReference("other:v1", name.WithDefaultRegistry(""))
+	refB2 := name.MustParseReference("other:v2", name.WithDefaultRegistry(""))
+
+	from := diffSIF(t, []v1.Image{img1, img2}, []name.Reference{refA, refB})
+	to := diffSIF(t, []v1.Image{changed, img2, img3}, []name.Reference{refA, refB2, nil})
+
+	t.Run("Identical", func(t *testing.T) {
+		d, err := from.Diff(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if d.From != d.To {
+			t.Errorf("got RootIndex %v, want %v", d.To, d.From)
+		}
+		if len(d.Refs) != 0 || len(d.Images) != 0 {
+			t.Errorf("got refs %v, images %v", d.Refs, d.Images)
+		}
+		if len(d.Blobs.Added) != 0 || len(d.Blobs.Removed) != 0 {
+			t.Errorf("got added %v, removed %v", d.Blobs.Added, d.Blobs.Removed)
+		}
+		if got, want := d.Blobs.Shared, 7; got != want {
+			t.Errorf("got %v shared blobs, want %v", got, want)
+		}
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		d, err := from.Diff(to)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		img1Digest, img1Config := imageBlobs(t, img1)
+		img2Digest, _ := imageBlobs(t, img2)
+		img3Digest, _ := imageBlobs(t, img3)
+		changedDigest, _ := imageBlobs(t, changed)
+
+		wantRefs := []sif.RefDiff{
+			{Name: refA.Name(), From: &img1Digest, To: &changedDigest},
+			{Name: refB.Name(), From: &img2Digest},
+			{Name: refB2.Name(), To: &img2Digest},
+		}
+		if got, want := d.Refs, wantRefs; !reflect.DeepEqual(got, want) {
+			t.Errorf("got refs %+v, want %+v", got, want)
+		}
+
+		if got, want := len(d.Images), 4; got != want {
+			t.Fatalf("got %v images, want %v", got, want)
+		}
+
+		// Images without a reference sort first.
+		if id := d.Images[0]; id.Ref != "" || id.From != nil || *id.To != img3Digest {
+			t.Errorf("got image %+v, want added %v", id, img3Digest)
+		}
+
+		id := d.Images[1]
+		if id.Ref != refA.Name() || *id.From != img1Digest || *id.To != changedDigest {
+			t.Errorf("got image %+v, want changed %v", id, refA.Name())
+		}
+		if !slices.ContainsFunc(id.Config, func(fd sif.FieldDiff) bool {
+			return fd.Path == "config.Env" && string(fd.To) == `["FOO=bar"]`
+		}) {
+			t.Errorf("got config %+v, want config.Env", id.Config)
+		}
+		if got, want := len(id.SharedLayers), 2; got != want {
+			t.Errorf("got %v shared layers, want %v", got, want)
+		}
+		ld, err := l.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := id.AddedLayers, []v1.Hash{ld}; !slices.Equal(got, want) {
+			t.Errorf("got added layers %v, want %v", got, want)
+		}
+		if len(id.RemovedLayers) != 0 {
+			t.Errorf("got removed layers %v", id.RemovedLayers)
+		}
+
+		if id := d.Images[2]; id.Ref != refB.Name() || *id.From != img2Digest || id.To != nil {
+			t.Errorf("got image %+v, want removed %v", id, refB.Name())
+		}
+		if id := d.Images[3]; id.Ref != refB2.Name() || id.From != nil || *id.To != img2Digest {
+			t.Errorf("got image %+v, want added %v", id, refB2.Name())
+		}
+
+		wantRemoved := []v1.Hash{img1Digest, img1Config}
+		slices.SortFunc(wantRemoved, func(a, b v1.Hash) int {
+			return cmp.Compare(a.String(), b.String())
+		})
+		if got, want := d.Blobs.Removed, wantRemoved; !slices.Equal(got, want) {
+			t.Errorf("got removed blobs %v, want %v", got, want)
+		}
+		if got, want := len(d.Blobs.Added), 6; got != want {
+			t.Errorf("got %v added blobs, want %v", got, want)
+		}
+		if got, want := d.Blobs.Shared, 5; got != want {
+			t.Errorf("got %v shared blobs, want %v", got, want)
+		}
+
+		if id := d.Images[0]; id.Digest == nil || *id.Digest != img3Digest {
+			t.Errorf("got image digest %v, want %v", id.Digest, img3Digest)
+		}
+
+		b, err := json.Marshal(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got sif.Diff
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&got, d) {
+			t.Errorf("JSON round trip: got %+v, want %+v", got, d)
+		}
+	})
+	t.Run("AmbiguousRef", func(t *testing.T) {
+		// Images in an index without platforms share a reference and platform, so must be
+		// identified by digest.
+		d, err := nestedSIF(t, refA, img1, img2).Diff(nestedSIF(t, refA, img1, img3))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		img2Digest, _ := imageBlobs(t, img2)
+		img3Digest, _ := imageBlobs(t, img3)
+
+		want := []sif.ImageDiff{
+			{Ref: refA.Name(), Digest: &img2Digest, From: &img2Digest},
+			{Ref: refA.Name(), Digest: &img3Digest, To: &img3Digest},
+		}
+		slices.SortFunc(want, func(a, b sif.ImageDiff) int {
+			return cmp.Compare(a.Digest.String(), b.Digest.String())
+		})
+		if got := d.Images; !reflect.DeepEqual(got, want) {
+			t.Errorf("got images %+v, want %+v", got, want)
+		}
+	})
+}
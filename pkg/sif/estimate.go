@@ -0,0 +1,270 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// WriteEstimate describes the SIF that would be written by Write.
+type WriteEstimate struct {
+	// Descriptors is the descriptor capacity of the SIF, including any spare capacity.
+	Descriptors int64
+	// BlobBytes is the total size of the blobs, including the RootIndex.
+	BlobBytes int64
+	// PaddingBytes is the total size of padding inserted before blobs to satisfy the alignment
+	// with which they are written. OCI blobs are currently written without alignment, so this is
+	// zero.
+	PaddingBytes int64
+	// HeaderBytes is the size of the SIF header and descriptor section, which precede the blobs.
+	HeaderBytes int64
+}
+
+// TotalBytes returns the size of the SIF.
+func (e *WriteEstimate) TotalBytes() int64 {
+	return e.HeaderBytes + e.BlobBytes + e.PaddingBytes
+}
+
+// Estimate returns an estimate of the SIF that would be written by Write, when called with ii and
+// opts. Only manifests and indexes are read from ii. The sizes of configs and layers are taken from
+// their descriptors.
+func Estimate(ii v1.ImageIndex, opts ...WriteOpt) (*WriteEstimate, error) {
+	wo := writeOpts{
+		spareDescriptors: 0,
+	}
+
+	for _, opt := range opts {
+		if err := opt(&wo); err != nil {
+			return nil, err
+		}
+	}
+
+	ds, err := indexWriteOrder(ii, make(map[v1.Hash]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	rootSize, err := ii.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	// The RootIndex is written last, and requires a descriptor of its own.
+	sizes := make([]int64, 0, len(ds)+1)
+	for _, d := range ds {
+		sizes = append(sizes, d.Size)
+	}
+	sizes = append(sizes, rootSize)
+
+	e := WriteEstimate{
+		Descriptors: int64(len(sizes)) + wo.spareDescriptors,
+	}
+
+	if e.HeaderBytes, err = headerBytes(e.Descriptors); err != nil {
+		return nil, err
+	}
+
+	offset := e.HeaderBytes
+	for _, size := range sizes {
+		padding := alignmentPadding(offset, blobAlignment)
+		e.PaddingBytes += padding
+		e.BlobBytes += size
+		offset += padding + size
+	}
+
+	return &e, nil
+}
+
+// alignmentPadding returns the number of bytes of padding required after offset to satisfy
+// alignment.
+func alignmentPadding(offset int64, alignment int64) int64 {
+	if alignment <= 1 || offset%alignment == 0 {
+		return 0
+	}
+	return alignment - offset%alignment
+}
+
+// indexWriteOrder returns descriptors of the blobs that writeIndex writes for ii, excluding ii
+// itself, in the order they are written. Blobs with digests in seen are skipped, and the digests
+// of blobs that are returned are added to seen.
+func indexWriteOrder(ii v1.ImageIndex, seen map[v1.Hash]bool) ([]v1.Descriptor, error) {
+	index, err := ii.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var ds []v1.Descriptor
+	add := func(d v1.Descriptor) {
+		if !seen[d.Digest] {
+			seen[d.Digest] = true
+			ds = append(ds, d)
+		}
+	}
+
+	for _, desc := range index.Manifests {
+		//nolint:exhaustive // Exhaustive cases not appropriate.
+		switch desc.MediaType {
+		case types.DockerManifestList, types.OCIImageIndex:
+			child, err := ii.ImageIndex(desc.Digest)
+			if err != nil {
+				return nil, err
+			}
+
+			cds, err := indexWriteOrder(child, seen)
+			if err != nil {
+				return nil, err
+			}
+			ds = append(ds, cds...)
+
+			add(desc)
+
+		case types.DockerManifestSchema2, types.OCIManifestSchema1:
+			img, err := ii.Image(desc.Digest)
+			if err != nil {
+				return nil, err
+			}
+
+			m, err := img.Manifest()
+			if err != nil {
+				return nil, err
+			}
+
+			d, err := partial.Descriptor(img)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, l := range m.Layers {
+				add(l)
+			}
+			add(m.Config)
+			add(*d)
+
+		default:
+			add(desc)
+		}
+	}
+
+	return ds, nil
+}
+
+// headerBytes returns the size of the header and descriptor section of a SIF with the specified
+// descriptor capacity.
+func headerBytes(capacity int64) (int64, error) {
+	fi, err := sif.CreateContainer(sif.NewBuffer(nil),
+		sif.OptCreateDeterministic(),
+		sif.OptCreateWithDescriptorCapacity(capacity),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = fi.UnloadContainer() }()
+
+	return fi.DataOffset(), nil
+}
+
+// UpdateEstimate describes the changes that would be made to a SIF by
+// OCIFileImage.UpdateRootIndex.
+type UpdateEstimate struct {
+	// Descriptors is the descriptor capacity required to apply the update. If it exceeds the
+	// capacity of the SIF, the SIF must be grown.
+	Descriptors int64
+	// AddedBytes is the total size of the blobs to be added, including the new RootIndex.
+	AddedBytes int64
+	// KeptBytes is the total size of the existing blobs to be kept.
+	KeptBytes int64
+	// DeletedBytes is the total size of the existing blobs to be deleted, including the existing
+	// RootIndex.
+	DeletedBytes int64
+}
+
+// EstimateUpdate returns an estimate of the changes that would be made to the SIF associated with f
+// by UpdateRootIndex, when called with ii and opts. Only manifests and indexes are read from ii.
+// The sizes of configs and layers are taken from their descriptors. The SIF is not modified.
+func (f *OCIFileImage) EstimateUpdate(ii v1.ImageIndex, opts ...UpdateOpt) (*UpdateEstimate, error) {
+	uo := updateOpts{}
+	for _, opt := range opts {
+		if err := opt(&uo); err != nil {
+			return nil, err
+		}
+	}
+
+	ds, err := f.sif.GetDescriptors(selectBlobsExcept(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	sifRootIndex, err := f.RootIndex()
+	if err != nil {
+		return nil, err
+	}
+	sifRootDigest, err := sifRootIndex.Digest()
+	if err != nil {
+		return nil, err
+	}
+	newRootDigest, err := ii.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	// If the existing RootIndex matches ii, then there is nothing to do.
+	if sifRootDigest == newRootDigest {
+		e := UpdateEstimate{
+			Descriptors: f.sif.DescriptorsTotal() - f.sif.DescriptorsFree(),
+		}
+		for _, d := range ds {
+			e.KeptBytes += d.Size()
+		}
+		return &e, nil
+	}
+
+	seen := make(map[v1.Hash]int64)
+	if _, err := numDescriptorsForIndex(ii, seen); err != nil {
+		return nil, err
+	}
+
+	rootSize, err := ii.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	e := UpdateEstimate{
+		AddedBytes: rootSize,
+	}
+
+	inSIF := make(map[v1.Hash]bool)
+	for _, d := range ds {
+		h, err := d.OCIBlobDigest()
+		if err != nil {
+			return nil, err
+		}
+		inSIF[h] = true
+
+		if _, ok := seen[h]; ok && d.DataType() == sif.DataOCIBlob {
+			e.KeptBytes += d.Size()
+		} else {
+			e.DeletedBytes += d.Size()
+		}
+	}
+
+	var cached, keep []v1.Hash
+	for h, size := range seen {
+		if inSIF[h] {
+			keep = append(keep, h)
+		} else {
+			cached = append(cached, h)
+			e.AddedBytes += size
+		}
+	}
+
+	if e.Descriptors, err = f.requiredDescriptors(uo.atomic, cached, keep); err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ggcrmutate "github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/sylabs/oci-tools/pkg/sif"
+	ssif "github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// ociBytes returns the total size of the OCI.RootIndex/OCI.Blob objects in fi.
+func ociBytes(t *testing.T, fi *ssif.FileImage) int64 {
+	t.Helper()
+
+	ds, err := fi.GetDescriptors(func(d ssif.Descriptor) (bool, error) {
+		return d.DataType() == ssif.DataOCIRootIndex || d.DataType() == ssif.DataOCIBlob, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int64
+	for _, d := range ds {
+		n += d.Size()
+	}
+	return n
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		opts []sif.WriteOpt
+	}{
+		{
+			name: "DockerManifest",
+			base: "hello-world-docker-v2-manifest",
+		},
+		{
+			name: "DockerManifestList",
+			base: "hello-world-docker-v2-manifest-list",
+		},
+		{
+			name: "CosignManifestList",
+			base: "hello-world-cosign-manifest-list",
+		},
+		{
+			name: "SpareDescriptors",
+			base: "hello-world-docker-v2-manifest",
+			opts: []sif.WriteOpt{sif.OptWriteWithSpareDescriptorCapacity(8)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ii := imageIndexFromPath(t, tt.base)
+
+			e, err := sif.Estimate(ii, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(t.TempDir(), "image.sif")
+			if err := sif.Write(path, ii, tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+
+			fi, err := ssif.LoadContainerFromPath(path, ssif.OptLoadWithFlag(os.O_RDONLY))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			if got, want := e.Descriptors, fi.DescriptorsTotal(); got != want {
+				t.Errorf("got %v descriptors, want %v", got, want)
+			}
+			if got, want := e.HeaderBytes, fi.DataOffset(); got != want {
+				t.Errorf("got %v header bytes, want %v", got, want)
+			}
+			if got, want := e.BlobBytes+e.PaddingBytes, fi.DataSize(); got != want {
+				t.Errorf("got %v blob and padding bytes, want %v", got, want)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := e.TotalBytes(), info.Size(); got != want {
+				t.Errorf("got %v total bytes, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_OCIFileImage_EstimateUpdate(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+	img, err := random.Image(64, 2, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		update func(v1.ImageIndex) v1.ImageIndex
+		atomic bool
+	}{
+		{
+			name:   "NoChange",
+			update: func(ri v1.ImageIndex) v1.ImageIndex { return ri },
+		},
+		{
+			name: "Append",
+			update: func(ri v1.ImageIndex) v1.ImageIndex {
+				return ggcrmutate.AppendManifests(ri, ggcrmutate.IndexAddendum{Add: img})
+			},
+		},
+		{
+			name: "AppendAtomic",
+			update: func(ri v1.ImageIndex) v1.ImageIndex {
+				return ggcrmutate.AppendManifests(ri, ggcrmutate.IndexAddendum{Add: img})
+			},
+			atomic: true,
+		},
+		{
+			name: "RemoveAll",
+			update: func(ri v1.ImageIndex) v1.ImageIndex {
+				return ggcrmutate.RemoveManifests(ri, func(v1.Descriptor) bool { return true })
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sifPath := corpus.SIF(t, "hello-world-docker-v2-manifest")
+
+			ofi, err := sif.LoadFromPath(sifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = ofi.Unload() })
+
+			ri, err := ofi.RootIndex()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ii := tt.update(ri)
+
+			opts := []sif.UpdateOpt{
+				sif.OptUpdateAtomic(tt.atomic),
+				sif.OptUpdateGrowth(sif.GrowExact),
+			}
+
+			e, err := ofi.EstimateUpdate(ii, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fi, err := ssif.LoadContainerFromPath(sifPath, ssif.OptLoadWithFlag(os.O_RDONLY))
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := ociBytes(t, fi)
+			if err := fi.UnloadContainer(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ofi.UpdateRootIndex(ii, opts...); err != nil {
+				t.Fatal(err)
+			}
+			if err := ofi.Unload(); err != nil {
+				t.Fatal(err)
+			}
+
+			fi, err = ssif.LoadContainerFromPath(sifPath, ssif.OptLoadWithFlag(os.O_RDONLY))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			if got, want := e.KeptBytes+e.DeletedBytes, before; got != want {
+				t.Errorf("got %v kept and deleted bytes, want %v", got, want)
+			}
+			if got, want := e.KeptBytes+e.AddedBytes, ociBytes(t, fi); got != want {
+				t.Errorf("got %v kept and added bytes, want %v", got, want)
+			}
+
+			// An atomic update requires capacity for old and new content at once, so the SIF
+			// is grown to exactly the estimate. Otherwise, the estimate is the number of
+			// objects that remain once the update is complete.
+			want := fi.DescriptorsTotal() - fi.DescriptorsFree()
+			if tt.atomic {
+				want = fi.DescriptorsTotal()
+			}
+			if got := e.Descriptors; got != want {
+				t.Errorf("got %v descriptors, want %v", got, want)
+			}
+		})
+	}
+}
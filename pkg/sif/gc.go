@@ -0,0 +1,181 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	"encoding/json"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// gcOpts accumulates garbage collection options.
+type gcOpts struct {
+	dryRun  bool
+	compact bool
+}
+
+// GCOpt are used to specify options to apply when garbage collecting a SIF.
+type GCOpt func(*gcOpts) error
+
+// OptGCDryRun sets whether garbage collection is a dry run. If b is true, the blobs that would be
+// removed are reported, but the SIF is not modified.
+func OptGCDryRun(b bool) GCOpt {
+	return func(c *gcOpts) error {
+		c.dryRun = b
+		return nil
+	}
+}
+
+// OptGCCompact sets whether the SIF is compacted after blobs are removed. By default, the SIF is
+// compacted, so that space occupied by removed blobs at the end of the data section is reclaimed.
+func OptGCCompact(b bool) GCOpt {
+	return func(c *gcOpts) error {
+		c.compact = b
+		return nil
+	}
+}
+
+// GCResult describes the blobs removed by OCIFileImage.GarbageCollect.
+type GCResult struct {
+	// Digests holds the digests of the blobs removed, in the order they appear in the SIF.
+	Digests []v1.Hash
+	// Bytes is the total size of the blobs removed.
+	Bytes int64
+}
+
+// GarbageCollect removes blobs from the SIF associated with f that are not reachable from the
+// RootIndex. A blob is reachable if it is an index or manifest referenced by the RootIndex,
+// directly or via a nested index, or if it is a config or layer referenced by such a manifest.
+// Cosign images and referrers are held in the RootIndex, and are therefore reachable along with
+// their blobs.
+//
+// By default, the SIF is compacted after unreachable blobs are removed. To override this,
+// consider using OptGCCompact. To determine which blobs would be removed without modifying the
+// SIF, consider using OptGCDryRun.
+func (f *OCIFileImage) GarbageCollect(opts ...GCOpt) (*GCResult, error) {
+	gco := gcOpts{
+		compact: true,
+	}
+	for _, opt := range opts {
+		if err := opt(&gco); err != nil {
+			return nil, err
+		}
+	}
+
+	reachable, err := f.reachableBlobs()
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := f.sif.GetDescriptors(sif.WithDataType(sif.DataOCIBlob))
+	if err != nil {
+		return nil, err
+	}
+
+	res := GCResult{}
+	for _, d := range ds {
+		h, err := d.OCIBlobDigest()
+		if err != nil {
+			return nil, err
+		}
+		if reachable[h] {
+			continue
+		}
+		res.Digests = append(res.Digests, h)
+		res.Bytes += d.Size()
+	}
+
+	if gco.dryRun || len(res.Digests) == 0 {
+		return &res, nil
+	}
+
+	err = f.sif.DeleteObjects(
+		func(d sif.Descriptor) (bool, error) {
+			if d.DataType() != sif.DataOCIBlob {
+				return false, nil
+			}
+			h, err := d.OCIBlobDigest()
+			if err != nil {
+				return false, err
+			}
+			return !reachable[h], nil
+		},
+		sif.OptDeleteZero(true),
+		sif.OptDeleteCompact(gco.compact),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// reachableBlobs returns the digests of all blobs that are reachable from the RootIndex of f.
+// Blobs that are referenced, but not present in f, are included. Their content cannot be
+// examined, so any blobs referenced only from them are not.
+func (f *OCIFileImage) reachableBlobs() (map[v1.Hash]bool, error) {
+	ri, err := f.RootIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	im, err := ri.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	reachable := make(map[v1.Hash]bool)
+
+	for queue := im.Manifests; len(queue) > 0; {
+		desc := queue[0]
+		queue = queue[1:]
+
+		if reachable[desc.Digest] {
+			continue
+		}
+		reachable[desc.Digest] = true
+
+		if !(desc.MediaType.IsImage() || desc.MediaType.IsIndex()) {
+			continue
+		}
+
+		// Duplicate blobs are tolerated, as they hold identical content.
+		ds, err := f.sif.GetDescriptors(
+			sif.WithDataType(sif.DataOCIBlob),
+			sif.WithOCIBlobDigest(desc.Digest),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(ds) == 0 {
+			continue
+		}
+
+		b, err := ds[0].GetData()
+		if err != nil {
+			return nil, err
+		}
+
+		var m struct {
+			Config    *v1.Descriptor  `json:"config,omitempty"`
+			Layers    []v1.Descriptor `json:"layers,omitempty"`
+			Manifests []v1.Descriptor `json:"manifests,omitempty"`
+		}
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+
+		if m.Config != nil {
+			reachable[m.Config.Digest] = true
+		}
+		for _, l := range m.Layers {
+			reachable[l.Digest] = true
+		}
+		queue = append(queue, m.Manifests...)
+	}
+
+	return reachable, nil
+}
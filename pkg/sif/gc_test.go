@@ -0,0 +1,144 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/sylabs/oci-tools/pkg/sif"
+	ssif "github.com/sylabs/sif/v2/pkg/sif"
+)
+
+func Test_OCIFileImage_GarbageCollect(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+	img, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		src         string
+		orphans     bool
+		opts        []sif.GCOpt
+		wantDigests int
+		wantBlobs   int
+		wantOK      bool
+	}{
+		{
+			name:      "Clean",
+			src:       "hello-world-docker-v2-manifest",
+			wantBlobs: 3,
+			wantOK:    true,
+		},
+		{
+			name:      "CleanCosign",
+			src:       "hello-world-cosign-manifest",
+			wantBlobs: 9,
+			wantOK:    true,
+		},
+		{
+			name:        "Orphans",
+			src:         "hello-world-docker-v2-manifest",
+			orphans:     true,
+			wantDigests: 3,
+			wantBlobs:   3,
+			wantOK:      true,
+		},
+		{
+			name:        "OrphansNoCompact",
+			src:         "hello-world-docker-v2-manifest",
+			orphans:     true,
+			opts:        []sif.GCOpt{sif.OptGCCompact(false)},
+			wantDigests: 3,
+			wantBlobs:   3,
+			wantOK:      true,
+		},
+		{
+			name:        "OrphansDryRun",
+			src:         "hello-world-docker-v2-manifest",
+			orphans:     true,
+			opts:        []sif.GCOpt{sif.OptGCDryRun(true)},
+			wantDigests: 3,
+			wantBlobs:   6,
+			wantOK:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sifPath := corpus.SIF(t, tt.src, sif.OptWriteWithSpareDescriptorCapacity(8))
+			fi, err := ssif.LoadContainerFromPath(sifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			ofi, err := sif.FromFileImage(fi)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var wantBytes int64
+			if tt.orphans {
+				addImageBlobs(t, fi, img)
+
+				sz, err := img.Size()
+				if err != nil {
+					t.Fatal(err)
+				}
+				m, err := img.Manifest()
+				if err != nil {
+					t.Fatal(err)
+				}
+				wantBytes = sz + m.Config.Size
+				for _, l := range m.Layers {
+					wantBytes += l.Size
+				}
+			}
+
+			res, err := ofi.GarbageCollect(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := len(res.Digests), tt.wantDigests; got != want {
+				t.Errorf("got %v digests, want %v", got, want)
+			}
+			if got, want := res.Bytes, wantBytes; got != want {
+				t.Errorf("got %v bytes, want %v", got, want)
+			}
+
+			if tt.orphans {
+				id, err := img.Digest()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Contains(res.Digests, id) {
+					t.Errorf("digest %v not collected", id)
+				}
+			}
+
+			ds, err := fi.GetDescriptors(ssif.WithDataType(ssif.DataOCIBlob))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(ds), tt.wantBlobs; got != want {
+				t.Errorf("got %v blobs, want %v", got, want)
+			}
+
+			// Remaining blobs must be intact, and orphans removed unless this is a dry run.
+			vr, err := ofi.Verify()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := vr.OK(), tt.wantOK; got != want {
+				t.Errorf("got OK %v, want %v: %v", got, want, vr.Err())
+			}
+		})
+	}
+}
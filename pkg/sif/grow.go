@@ -0,0 +1,207 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// GrowthPolicy returns the descriptor capacity that a SIF with the specified capacity is grown
+// to, when at least required descriptors are needed.
+type GrowthPolicy func(capacity, required int64) int64
+
+// GrowExact is a GrowthPolicy that grows the descriptor capacity of a SIF to exactly the number
+// of descriptors required.
+func GrowExact(_, required int64) int64 {
+	return required
+}
+
+// GrowDouble is a GrowthPolicy that doubles the descriptor capacity of a SIF, as many times as
+// necessary to hold the number of descriptors required.
+func GrowDouble(capacity, required int64) int64 {
+	capacity = max(capacity, 1)
+	for capacity < required {
+		capacity *= 2
+	}
+	return capacity
+}
+
+// rawMetadata holds the raw bytes of the metadata of a descriptor.
+type rawMetadata []byte
+
+func (m rawMetadata) MarshalBinary() ([]byte, error) { return m, nil }
+
+func (m *rawMetadata) UnmarshalBinary(b []byte) error {
+	*m = append((*m)[:0], b...)
+	return nil
+}
+
+// descriptorInputOpts returns options that reproduce the attributes of d.
+func descriptorInputOpts(d sif.Descriptor) ([]sif.DescriptorInputOpt, error) {
+	opts := []sif.DescriptorInputOpt{
+		sif.OptObjectName(d.Name()),
+		sif.OptObjectTime(d.CreatedAt()),
+	}
+
+	if g := d.GroupID(); g == 0 {
+		opts = append(opts, sif.OptNoGroup())
+	} else {
+		opts = append(opts, sif.OptGroupID(g))
+	}
+
+	if id, isGroup := d.LinkedID(); id != 0 && isGroup {
+		opts = append(opts, sif.OptLinkedGroupID(id))
+	} else if id != 0 {
+		opts = append(opts, sif.OptLinkedID(id))
+	}
+
+	//nolint:exhaustive // Exhaustive cases not appropriate.
+	switch d.DataType() {
+	case sif.DataOCIRootIndex, sif.DataOCIBlob:
+		// The digest is recomputed as the object is written.
+
+	case sif.DataPartition:
+		fs, pt, arch, err := d.PartitionMetadata()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sif.OptPartitionMetadata(fs, pt, arch))
+
+	case sif.DataSignature:
+		ht, fp, err := d.SignatureMetadata()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sif.OptSignatureMetadata(ht, fp))
+
+	case sif.DataCryptoMessage:
+		ft, mt, err := d.CryptoMessageMetadata()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sif.OptCryptoMessageMetadata(ft, mt))
+
+	case sif.DataSBOM:
+		f, err := d.SBOMMetadata()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sif.OptSBOMMetadata(f))
+
+	default:
+		var md rawMetadata
+		if err := d.GetMetadata(&md); err != nil {
+			return nil, err
+		}
+		opts = append(opts, sif.OptMetadata(md))
+	}
+
+	return opts, nil
+}
+
+// grow rewrites the SIF associated with f so that it has the specified descriptor capacity. All
+// objects, including those that are not OCI blobs, are preserved along with their attributes and
+// object IDs, so that existing signatures remain valid. The SIF is written to a temporary file in
+// the same directory, which replaces the original once complete.
+func (f *OCIFileImage) grow(capacity int64) error {
+	ds, err := f.sif.GetDescriptors()
+	if err != nil {
+		return err
+	}
+
+	// Objects are assigned IDs according to the order in which they are written, so unused
+	// descriptors are filled with placeholders, which are deleted once the SIF is created.
+	byID := make(map[uint32]sif.Descriptor, len(ds))
+	var maxID uint32
+	for _, d := range ds {
+		byID[d.ID()] = d
+		maxID = max(maxID, d.ID())
+	}
+
+	dis := make([]sif.DescriptorInput, 0, maxID)
+	var placeholders []uint32
+	for id := uint32(1); id <= maxID; id++ {
+		d, ok := byID[id]
+		if !ok {
+			di, err := sif.NewDescriptorInput(sif.DataGeneric, bytes.NewReader(nil), sif.OptNoGroup())
+			if err != nil {
+				return err
+			}
+			dis = append(dis, di)
+			placeholders = append(placeholders, id)
+			continue
+		}
+
+		opts, err := descriptorInputOpts(d)
+		if err != nil {
+			return err
+		}
+
+		di, err := sif.NewDescriptorInput(d.DataType(), d.GetReader(), opts...)
+		if err != nil {
+			return err
+		}
+		dis = append(dis, di)
+	}
+
+	fileInfo, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+
+	tf, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tf.Name())
+
+	if err := tf.Chmod(fileInfo.Mode().Perm()); err != nil {
+		_ = tf.Close()
+		return err
+	}
+
+	fi, err := sif.CreateContainer(tf,
+		sif.OptCreateWithID(f.sif.ID()),
+		sif.OptCreateWithLaunchScript(f.sif.LaunchScript()),
+		sif.OptCreateWithTime(f.sif.CreatedAt()),
+		sif.OptCreateWithDescriptorCapacity(capacity),
+		sif.OptCreateWithDescriptors(dis...),
+		sif.OptCreateWithCloseOnUnload(true),
+	)
+	if err != nil {
+		_ = tf.Close()
+		return err
+	}
+
+	if len(placeholders) > 0 {
+		err := fi.DeleteObjects(func(d sif.Descriptor) (bool, error) {
+			return slices.Contains(placeholders, d.ID()), nil
+		}, sif.OptDeleteWithTime(f.sif.ModifiedAt()))
+		if err != nil {
+			_ = fi.UnloadContainer()
+			return err
+		}
+	}
+
+	if err := fi.UnloadContainer(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tf.Name(), f.path); err != nil {
+		return err
+	}
+
+	if err := f.sif.UnloadContainer(); err != nil {
+		return err
+	}
+
+	f.sif, err = sif.LoadContainerFromPath(f.path)
+	return err
+}
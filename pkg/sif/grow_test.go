@@ -0,0 +1,301 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sylabs/oci-tools/pkg/sif"
+	"github.com/sylabs/sif/v2/pkg/integrity"
+	ssif "github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// addLinkedObjects adds a generic object, and a signature linked to it, to the SIF at path.
+func addLinkedObjects(t *testing.T, path string) {
+	t.Helper()
+
+	fi, err := ssif.LoadContainerFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = fi.UnloadContainer() }()
+
+	di, err := ssif.NewDescriptorInput(ssif.DataGeneric, bytes.NewReader([]byte("generic")),
+		ssif.OptObjectName("generic"),
+		ssif.OptNoGroup(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fi.AddObject(di); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := fi.GetDescriptor(ssif.WithDataType(ssif.DataGeneric))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	di, err = ssif.NewDescriptorInput(ssif.DataSignature, bytes.NewReader([]byte("signature")),
+		ssif.OptObjectName("signature"),
+		ssif.OptNoGroup(),
+		ssif.OptLinkedID(d.ID()),
+		ssif.OptSignatureMetadata(crypto.SHA256, []byte("fingerprint")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fi.AddObject(di); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkLinkedObjects checks that the objects added by addLinkedObjects are present in fi.
+func checkLinkedObjects(t *testing.T, fi *ssif.FileImage) {
+	t.Helper()
+
+	d, err := fi.GetDescriptor(ssif.WithDataType(ssif.DataGeneric))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.Name(), "generic"; got != want {
+		t.Errorf("got name %q, want %q", got, want)
+	}
+
+	sd, err := fi.GetDescriptor(ssif.WithDataType(ssif.DataSignature))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, isGroup := sd.LinkedID(); isGroup || id != d.ID() {
+		t.Errorf("got linked ID %v (group %v), want %v", id, isGroup, d.ID())
+	}
+	ht, fp, err := sd.SignatureMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht != crypto.SHA256 || !bytes.HasPrefix(fp, []byte("fingerprint")) {
+		t.Errorf("got signature metadata %v %q", ht, fp)
+	}
+	b, err := sd.GetData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "signature"; got != want {
+		t.Errorf("got signature %q, want %q", got, want)
+	}
+}
+
+func TestAppendGrowth(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+	img, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		fromFileImage bool
+		opts          []sif.AppendOpt
+		wantErr       bool
+		wantTotal     int64
+	}{
+		{
+			name:      "Default",
+			wantTotal: 12,
+		},
+		{
+			name:      "Exact",
+			opts:      []sif.AppendOpt{sif.OptAppendGrowth(sif.GrowExact)},
+			wantTotal: 9,
+		},
+		{
+			name:      "ExactAtomic",
+			opts:      []sif.AppendOpt{sif.OptAppendGrowth(sif.GrowExact), sif.OptAppendAtomic(true)},
+			wantTotal: 10,
+		},
+		{
+			name:      "Disabled",
+			opts:      []sif.AppendOpt{sif.OptAppendGrowth(nil)},
+			wantErr:   true,
+			wantTotal: 6,
+		},
+		{
+			name:          "FromFileImage",
+			fromFileImage: true,
+			wantErr:       true,
+			wantTotal:     6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sifPath := corpus.SIF(t, "hello-world-docker-v2-manifest", sif.OptWriteWithSpareDescriptorCapacity(2))
+			addLinkedObjects(t, sifPath)
+
+			var ofi *sif.OCIFileImage
+			if tt.fromFileImage {
+				fi, err := ssif.LoadContainerFromPath(sifPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+				if ofi, err = sif.FromFileImage(fi); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				if ofi, err = sif.LoadFromPath(sifPath); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { _ = ofi.Unload() })
+			}
+
+			err := ofi.AppendImage(img, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AppendImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if err := ofi.Unload(); err != nil {
+				t.Fatal(err)
+			}
+
+			fi, err := ssif.LoadContainerFromPath(sifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			if got, want := fi.DescriptorsTotal(), tt.wantTotal; got != want {
+				t.Errorf("got %v descriptors, want %v", got, want)
+			}
+
+			checkLinkedObjects(t, fi)
+
+			ofi, err = sif.FromFileImage(fi)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ms, err := ofi.FindManifests(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(ms), 2; got != want {
+				t.Errorf("got %v manifests, want %v", got, want)
+			}
+
+			vr, err := ofi.Verify()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := vr.Err(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// addSignedGroup adds two generic objects in group 2 to the SIF at path, with a gap between their
+// object IDs, and signs the group with sv.
+func addSignedGroup(t *testing.T, path string, sv signature.SignerVerifier) {
+	t.Helper()
+
+	fi, err := ssif.LoadContainerFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = fi.UnloadContainer() }()
+
+	for _, name := range []string{"first", "gap", "second"} {
+		opt := ssif.OptGroupID(2)
+		if name == "gap" {
+			opt = ssif.OptNoGroup()
+		}
+
+		di, err := ssif.NewDescriptorInput(ssif.DataGeneric, bytes.NewReader([]byte(name)),
+			ssif.OptObjectName(name),
+			opt,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fi.AddObject(di); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := integrity.NewSigner(fi, integrity.OptSignWithSigner(sv), integrity.OptSignGroup(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Sign(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = fi.DeleteObjects(func(d ssif.Descriptor) (bool, error) {
+		return d.Name() == "gap", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppendGrowthSigned(t *testing.T) {
+	img, err := random.Image(64, 1, random.WithSource(rand.NewSource(randomSeed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signature.LoadED25519SignerVerifier(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sifPath := corpus.SIF(t, "hello-world-docker-v2-manifest", sif.OptWriteWithSpareDescriptorCapacity(4))
+	addSignedGroup(t, sifPath, sv)
+
+	ofi, err := sif.LoadFromPath(sifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ofi.Unload() })
+
+	if err := ofi.AppendImage(img); err != nil {
+		t.Fatal(err)
+	}
+	if err := ofi.Unload(); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := ssif.LoadContainerFromPath(sifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+	if got, want := fi.DescriptorsTotal(), int64(16); got != want {
+		t.Errorf("got %v descriptors, want %v", got, want)
+	}
+
+	v, err := integrity.NewVerifier(fi, integrity.OptVerifyWithVerifier(sv), integrity.OptVerifyGroup(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(); err != nil {
+		t.Error(err)
+	}
+}
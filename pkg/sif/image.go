@@ -1,4 +1,4 @@
-// Copyright 2023 Sylabs Inc. All rights reserved.
+// Copyright 2023-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -21,13 +21,23 @@ type image struct {
 	f           *OCIFileImage
 	desc        *v1.Descriptor
 	rawManifest []byte
+	o           options
 }
 
 // Image returns a single Image stored in f, that is selected by m. If m is nil, all manifests are
 // selected. If more than one image matches, an error wrapping ErrMultipleMatches is returned. If
 // no image matches, an error wrapping ErrNoMatch is returned.
-func (f *OCIFileImage) Image(m match.Matcher, _ ...Option) (v1.Image, error) {
-	ri, err := f.RootIndex()
+//
+// By default, the diff IDs of layers are read from the image config. To verify them against the
+// layer content, consider using OptVerifyDiffIDs. To verify the content of blobs as they are read,
+// consider using OptVerifyBlobs.
+func (f *OCIFileImage) Image(m match.Matcher, opts ...Option) (v1.Image, error) {
+	o, err := getOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ri, err := f.rootIndex(o)
 	if err != nil {
 		return nil, err
 	}
@@ -58,19 +68,39 @@ func (im *image) Layers() ([]v1.Layer, error) {
 		return nil, err
 	}
 
-	ls := make([]v1.Layer, len(m.Layers))
-	for i, d := range m.Layers {
-		l, err := im.LayerByDigest(d.Digest)
-		if err != nil {
-			return nil, err
-		}
+	diffIDs := im.diffIDs(m)
 
-		ls[i] = l
+	ls := make([]v1.Layer, len(m.Layers))
+	for i := range m.Layers {
+		ls[i] = im.layer(m, diffIDs, i)
 	}
 
 	return ls, nil
 }
 
+// diffIDs returns the diff IDs of the layers in m, as recorded in the config of im. If the config
+// cannot be parsed, or does not hold a diff ID for each layer, nil is returned.
+func (im *image) diffIDs(m *v1.Manifest) []v1.Hash {
+	cfg, err := im.ConfigFile()
+	if err != nil || len(cfg.RootFS.DiffIDs) != len(m.Layers) {
+		return nil
+	}
+	return cfg.RootFS.DiffIDs
+}
+
+// layer returns the i'th layer of m, with the diff ID from diffIDs, if known.
+func (im *image) layer(m *v1.Manifest, diffIDs []v1.Hash, i int) *Layer {
+	l := &Layer{
+		f:    im.f,
+		desc: m.Layers[i],
+		o:    im.o,
+	}
+	if diffIDs != nil {
+		l.configDiffID = &diffIDs[i]
+	}
+	return l
+}
+
 // MediaType of this image's manifest.
 func (im *image) MediaType() (types.MediaType, error) {
 	return im.desc.MediaType, nil
@@ -109,7 +139,7 @@ func (im *image) RawConfigFile() ([]byte, error) {
 		return nil, err
 	}
 
-	return im.f.Bytes(manifest.Config.Digest)
+	return im.f.bytes(manifest.Config.Digest, manifest.Config.Size, im.o)
 }
 
 // Digest returns the sha256 of this image's manifest.
@@ -143,12 +173,9 @@ func (im *image) LayerByDigest(h v1.Hash) (v1.Layer, error) {
 		return nil, err
 	}
 
-	for _, desc := range manifest.Layers {
+	for i, desc := range manifest.Layers {
 		if h == desc.Digest {
-			return &Layer{
-				f:    im.f,
-				desc: desc,
-			}, nil
+			return im.layer(manifest, im.diffIDs(manifest), i), nil
 		}
 	}
 
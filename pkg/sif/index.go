@@ -1,4 +1,4 @@
-// Copyright 2023 Sylabs Inc. All rights reserved.
+// Copyright 2023-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -26,7 +26,7 @@ var _ v1.ImageIndex = (*imageIndex)(nil)
 // Deprecated: Use OCIFileImage.RootIndex instead. ImageIndexFromFileImage will
 // be removed in a future version.
 func ImageIndexFromFileImage(fi *sif.FileImage) (v1.ImageIndex, error) {
-	f := &OCIFileImage{fi}
+	f := &OCIFileImage{sif: fi}
 
 	return f.RootIndex()
 }
@@ -35,10 +35,16 @@ type imageIndex struct {
 	f           *OCIFileImage
 	desc        *v1.Descriptor
 	rawManifest []byte
+	o           options
 }
 
 // RootIndex returns the RootIndex of f as a v1.ImageIndex.
 func (f *OCIFileImage) RootIndex() (v1.ImageIndex, error) {
+	return f.rootIndex(options{})
+}
+
+// rootIndex returns the RootIndex of f, which applies o to the images and indexes it references.
+func (f *OCIFileImage) rootIndex(o options) (v1.ImageIndex, error) {
 	d, err := f.sif.GetDescriptor(
 		sif.WithDataType(sif.DataOCIRootIndex),
 	)
@@ -46,6 +52,17 @@ func (f *OCIFileImage) RootIndex() (v1.ImageIndex, error) {
 		return nil, err
 	}
 
+	ix, err := f.indexFromDescriptor(d)
+	if err != nil {
+		return nil, err
+	}
+	ix.o = o
+
+	return ix, nil
+}
+
+// indexFromDescriptor returns the index held in the object described by d.
+func (f *OCIFileImage) indexFromDescriptor(d sif.Descriptor) (*imageIndex, error) {
 	b, err := d.GetData()
 	if err != nil {
 		return nil, err
@@ -70,8 +87,17 @@ func (f *OCIFileImage) RootIndex() (v1.ImageIndex, error) {
 // Index returns a single ImageIndex stored in f, that is selected by m. If m is nil, all manifests
 // are selected. If more than one index matches, an error wrapping ErrMultipleMatches is returned.
 // If no index matches, an error wrapping ErrNoMatch is returned.
-func (f *OCIFileImage) Index(m match.Matcher, _ ...Option) (v1.ImageIndex, error) {
-	ri, err := f.RootIndex()
+//
+// To verify the diff IDs of layers in images referenced by the index, consider using
+// OptVerifyDiffIDs. To verify the content of blobs as they are read, consider using
+// OptVerifyBlobs.
+func (f *OCIFileImage) Index(m match.Matcher, opts ...Option) (v1.ImageIndex, error) {
+	o, err := getOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ri, err := f.rootIndex(o)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +165,7 @@ func (ix *imageIndex) Image(h v1.Hash) (v1.Image, error) {
 		return nil, fmt.Errorf("%w for %v: %v", errUnexpectedMediaType, h, desc.MediaType)
 	}
 
-	b, err := ix.f.Bytes(h)
+	b, err := ix.f.bytes(h, desc.Size, ix.o)
 	if err != nil {
 		return nil, err
 	}
@@ -148,6 +174,7 @@ func (ix *imageIndex) Image(h v1.Hash) (v1.Image, error) {
 		f:           ix.f,
 		desc:        desc,
 		rawManifest: b,
+		o:           ix.o,
 	}
 	return &img, nil
 }
@@ -163,7 +190,7 @@ func (ix *imageIndex) ImageIndex(h v1.Hash) (v1.ImageIndex, error) {
 		return nil, fmt.Errorf("%w for %v: %v", errUnexpectedMediaType, h, desc.MediaType)
 	}
 
-	b, err := ix.f.Bytes(h)
+	b, err := ix.f.bytes(h, desc.Size, ix.o)
 	if err != nil {
 		return nil, err
 	}
@@ -172,6 +199,28 @@ func (ix *imageIndex) ImageIndex(h v1.Hash) (v1.ImageIndex, error) {
 		f:           ix.f,
 		desc:        desc,
 		rawManifest: b,
+		o:           ix.o,
+	}, nil
+}
+
+// Layer returns a v1.Layer for a blob that this ImageIndex references directly, such as an
+// artifact that is not wrapped in an image manifest. The v1.ImageIndex interface does not expose
+// arbitrary blobs, so this method follows the workaround used by mutate and remote
+// (https://github.com/google/go-containerregistry/issues/819).
+func (ix *imageIndex) Layer(h v1.Hash) (v1.Layer, error) {
+	desc, err := ix.findDescriptor(h)
+	if err != nil {
+		return nil, err
+	}
+
+	if mt := desc.MediaType; mt.IsImage() || mt.IsIndex() {
+		return nil, fmt.Errorf("%w for %v: %v", errUnexpectedMediaType, h, desc.MediaType)
+	}
+
+	return &Layer{
+		f:    ix.f,
+		desc: *desc,
+		o:    ix.o,
 	}, nil
 }
 
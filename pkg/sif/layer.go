@@ -1,11 +1,13 @@
-// Copyright 2023 Sylabs Inc. All rights reserved.
+// Copyright 2023-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
 package sif
 
 import (
+	"fmt"
 	"io"
+	"sync"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
@@ -17,6 +19,14 @@ var _ v1.Layer = (*Layer)(nil)
 type Layer struct {
 	f    *OCIFileImage
 	desc v1.Descriptor
+
+	// configDiffID is the diff ID of the layer recorded in the image config, if known.
+	configDiffID *v1.Hash
+	// o holds the options that apply to reads of the layer.
+	o options
+
+	mu     sync.Mutex
+	diffID *v1.Hash // diffID caches the computed diff ID.
 }
 
 // Digest returns the Hash of the compressed layer.
@@ -24,21 +34,49 @@ func (l *Layer) Digest() (v1.Hash, error) {
 	return l.desc.Digest, nil
 }
 
-// DiffID returns the Hash of the uncompressed layer.
+// DiffID returns the Hash of the uncompressed layer. If the layer belongs to an image, the diff ID
+// recorded in the image config is returned, unless verification was requested with
+// OptVerifyDiffIDs. Otherwise, the diff ID is computed from the uncompressed layer, and cached.
 func (l *Layer) DiffID() (v1.Hash, error) {
-	r, err := l.Uncompressed()
-	if err != nil {
-		return v1.Hash{}, err
+	if l.configDiffID != nil && !l.o.verifyDiffIDs {
+		return *l.configDiffID, nil
 	}
-	defer r.Close()
 
-	h, _, err := v1.SHA256(r)
-	return h, err
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.diffID == nil {
+		r, err := l.Uncompressed()
+		if err != nil {
+			return v1.Hash{}, err
+		}
+		defer r.Close()
+
+		h, _, err := v1.SHA256(r)
+		if err != nil {
+			return v1.Hash{}, err
+		}
+		l.diffID = &h
+	}
+
+	if l.configDiffID != nil && *l.diffID != *l.configDiffID {
+		return v1.Hash{}, fmt.Errorf("%w: got %v, want %v", ErrDiffIDMismatch, *l.diffID, *l.configDiffID)
+	}
+
+	return *l.diffID, nil
 }
 
-// Compressed returns an io.ReadCloser for the compressed layer contents.
+// Compressed returns an io.ReadCloser for the compressed layer contents. If verification was
+// requested with OptVerifyBlobs, the contents are checked against the digest and size of the layer
+// as they are read.
 func (l *Layer) Compressed() (io.ReadCloser, error) {
-	return l.f.Blob(l.desc.Digest)
+	return l.f.blob(l.desc.Digest, l.desc.Size, l.o)
+}
+
+// ReaderAt returns a SectionReader that provides random access to the compressed layer contents.
+// See OCIFileImage.BlobReaderAt.
+func (l *Layer) ReaderAt() (*io.SectionReader, error) {
+	return l.f.BlobReaderAt(l.desc.Digest)
 }
 
 // Uncompressed returns an io.ReadCloser for the uncompressed layer contents.
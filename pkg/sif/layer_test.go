@@ -1,14 +1,22 @@
-// Copyright 2023-2025 Sylabs Inc. All rights reserved.
+// Copyright 2023-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
 package sif_test
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ggcrempty "github.com/google/go-containerregistry/pkg/v1/empty"
+	ggcrmutate "github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/sylabs/oci-tools/pkg/sif"
 )
 
@@ -104,3 +112,147 @@ func TestLayer_Offset(t *testing.T) {
 		})
 	}
 }
+
+func TestLayer_ReaderAt(t *testing.T) {
+	tests := []struct {
+		name string
+		l    v1.Layer
+	}{
+		{
+			name: "DockerManifest",
+			l: layerFromPath(t, "hello-world-docker-v2-manifest",
+				"sha256:432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338",
+				"sha256:7050e35b49f5e348c4809f5eff915842962cb813f32062d3bbdd35c750dd7d01",
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, ok := tt.l.(*sif.Layer)
+			if !ok {
+				t.Fatalf("unexpected layer type: %T", tt.l)
+			}
+
+			rc, err := l.Compressed()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+
+			want, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sr, err := l.ReaderAt()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := sr.Size(), int64(len(want)); got != want {
+				t.Errorf("got size %v, want %v", got, want)
+			}
+
+			// Read the second half of the layer, then the first, to exercise random access.
+			got := make([]byte, len(want))
+			half := len(want) / 2
+			if _, err := sr.ReadAt(got[half:], int64(half)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := sr.ReadAt(got[:half], 0); err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("content read at offsets does not match compressed layer")
+			}
+		})
+	}
+}
+
+func TestLayer_DiffID(t *testing.T) {
+	img, err := random.Image(64, 1, random.WithSource(rand.NewSource(randomSeed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffID, err := ls[0].DiffID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badImg := badDiffIDImage(t)
+	badCfg, err := badImg.ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		img        v1.Image
+		opts       []sif.Option
+		wantDiffID v1.Hash
+		wantErr    error
+	}{
+		{
+			name:       "Config",
+			img:        img,
+			wantDiffID: diffID,
+		},
+		{
+			name:       "ConfigMismatch",
+			img:        badImg,
+			wantDiffID: badCfg.RootFS.DiffIDs[0],
+		},
+		{
+			name:       "Verify",
+			img:        img,
+			opts:       []sif.Option{sif.OptVerifyDiffIDs(true)},
+			wantDiffID: diffID,
+		},
+		{
+			name:    "VerifyMismatch",
+			img:     badImg,
+			opts:    []sif.Option{sif.OptVerifyDiffIDs(true)},
+			wantErr: sif.ErrDiffIDMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "image.sif")
+			ii := ggcrmutate.AppendManifests(ggcrempty.Index, ggcrmutate.IndexAddendum{Add: tt.img})
+			if err := sif.Write(path, ii); err != nil {
+				t.Fatal(err)
+			}
+
+			ofi, err := sif.LoadFromPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = ofi.Unload() })
+
+			img, err := ofi.Image(nil, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ls, err := img.Layers()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Call twice, to exercise any cached value.
+			for range 2 {
+				h, err := ls[0].DiffID()
+				if got, want := err, tt.wantErr; !errors.Is(got, want) {
+					t.Fatalf("got error %v, want %v", got, want)
+				}
+				if got, want := h, tt.wantDiffID; got != want {
+					t.Errorf("got diff ID %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	"io"
+	"os"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// ExportLayout writes the content of f to an OCI Image Layout at dir, which is created if it does
+// not exist. The index.json of the layout holds the RootIndex of f, byte for byte, so that its
+// digest, and any annotations it holds, are preserved. Every blob in f is written to the layout,
+// including those of cosign images and referrers. Blob content is streamed from f.
+//
+// Any existing blobs in the layout are retained, but its index.json is replaced.
+func (f *OCIFileImage) ExportLayout(dir string) error {
+	ri, err := f.RootIndex()
+	if err != nil {
+		return err
+	}
+
+	rm, err := ri.RawManifest()
+	if err != nil {
+		return err
+	}
+
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		return err
+	}
+
+	ds, err := f.sif.GetDescriptors(sif.WithDataType(sif.DataOCIBlob))
+	if err != nil {
+		return err
+	}
+
+	written := make(map[v1.Hash]bool)
+	for _, d := range ds {
+		h, err := d.OCIBlobDigest()
+		if err != nil {
+			return err
+		}
+		if written[h] {
+			continue
+		}
+		written[h] = true
+
+		if err := p.WriteBlob(h, io.NopCloser(d.GetReader())); err != nil {
+			return err
+		}
+	}
+
+	return p.WriteFile("index.json", rm, os.ModePerm)
+}
+
+// ImportLayout constructs a SIF at path from the OCI Image Layout at dir. The index.json of the
+// layout becomes the RootIndex of the SIF, byte for byte, so that its digest, and any annotations
+// it holds, are preserved. Blob content is streamed from the layout.
+//
+// The SIF is written as if by Write, with the specified opts.
+func ImportLayout(dir, path string, opts ...WriteOpt) error {
+	ii, err := layout.ImageIndexFromPath(dir)
+	if err != nil {
+		return err
+	}
+
+	return Write(path, ii, opts...)
+}
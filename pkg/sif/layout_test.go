@@ -0,0 +1,110 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/validate"
+	"github.com/sylabs/oci-tools/pkg/sif"
+	ssif "github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// rawRootIndex returns the RootIndex of the SIF at path.
+func rawRootIndex(t *testing.T, path string) []byte {
+	t.Helper()
+
+	fi, err := ssif.LoadContainerFromPath(path, ssif.OptLoadWithFlag(os.O_RDONLY))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = fi.UnloadContainer() }()
+
+	ofi, err := sif.FromFileImage(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vr, err := ofi.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vr.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	ri, err := ofi.RootIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ri.RawManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestExportImportLayout(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"DockerManifest", "hello-world-docker-v2-manifest"},
+		{"DockerManifestList", "hello-world-docker-v2-manifest-list"},
+		{"CosignManifest", "hello-world-cosign-manifest"},
+		{"CosignManifestList", "hello-world-cosign-manifest-list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srcPath := corpus.SIF(t, tt.src)
+			want := rawRootIndex(t, srcPath)
+
+			fi, err := ssif.LoadContainerFromPath(srcPath, ssif.OptLoadWithFlag(os.O_RDONLY))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			ofi, err := sif.FromFileImage(fi)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dir := t.TempDir()
+			if err := ofi.ExportLayout(dir); err != nil {
+				t.Fatalf("ExportLayout() error = %v", err)
+			}
+
+			got, err := os.ReadFile(filepath.Join(dir, "index.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got index.json %s, want %s", got, want)
+			}
+
+			ii, err := layout.ImageIndexFromPath(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := validate.Index(ii); err != nil {
+				t.Errorf("invalid layout: %v", err)
+			}
+
+			dstPath := filepath.Join(t.TempDir(), "image.sif")
+			if err := sif.ImportLayout(dir, dstPath); err != nil {
+				t.Fatalf("ImportLayout() error = %v", err)
+			}
+
+			if got := rawRootIndex(t, dstPath); !bytes.Equal(got, want) {
+				t.Errorf("got RootIndex %s, want %s", got, want)
+			}
+		})
+	}
+}
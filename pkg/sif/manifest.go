@@ -1,4 +1,4 @@
-// Copyright 2024 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -6,6 +6,7 @@ package sif
 
 import (
 	"encoding/json"
+	"fmt"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/match"
@@ -53,6 +54,15 @@ func (in *editedManifest) ImageIndex(h v1.Hash) (v1.ImageIndex, error) {
 	return in.base.ImageIndex(h)
 }
 
+// Layer returns a v1.Layer for a blob that this ImageIndex references directly.
+func (in *editedManifest) Layer(h v1.Hash) (v1.Layer, error) {
+	wl, ok := in.base.(withLayer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", errUnableToReadBlob, h)
+	}
+	return wl.Layer(h)
+}
+
 type descriptorEditFunc func(desc v1.Descriptor) v1.Descriptor
 
 // editManifestDescriptors edits the index manifest described by ii. Each descriptor that matches m
@@ -1,4 +1,4 @@
-// Copyright 2024 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -25,3 +25,11 @@ func matchAllIfNil(m match.Matcher) match.Matcher {
 	}
 	return matchAll
 }
+
+// ArtifactType returns a matcher that selects descriptors with the specified artifactType. It can
+// be used to filter the result of OCIFileImage.Referrers.
+func ArtifactType(t string) match.Matcher {
+	return func(desc v1.Descriptor) bool {
+		return desc.ArtifactType == t
+	}
+}
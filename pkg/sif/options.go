@@ -1,4 +1,4 @@
-// Copyright 2024 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -7,4 +7,40 @@ package sif
 // Option is a functional option for OCIFileImage operations.
 type Option func(*options) error
 
-type options struct{}
+type options struct {
+	verifyDiffIDs bool
+	verifyBlobs   bool
+}
+
+// OptVerifyDiffIDs sets whether the diff IDs of layers are verified. By default, the diff ID of a
+// layer is read from the `rootfs.diff_ids` of its image config, without reading the layer. When b
+// is true, the diff ID is instead computed from the uncompressed layer, and an error wrapping
+// ErrDiffIDMismatch is returned if it does not match the image config.
+func OptVerifyDiffIDs(b bool) Option {
+	return func(o *options) error {
+		o.verifyDiffIDs = b
+		return nil
+	}
+}
+
+// OptVerifyBlobs sets whether the content of blobs is verified as it is read. By default, the
+// content of a blob is trusted to match its digest. When b is true, the content is hashed as it is
+// read, and an error wrapping ErrBlobDigestMismatch or ErrBlobSizeMismatch is returned in place of
+// io.EOF if the content, or its size where known, does not match.
+func OptVerifyBlobs(b bool) Option {
+	return func(o *options) error {
+		o.verifyBlobs = b
+		return nil
+	}
+}
+
+// getOptions returns the options that result from applying opts.
+func getOptions(opts ...Option) (options, error) {
+	var o options
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return options{}, err
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// verifyReader is an io.Reader that checks the content read from r against a digest and size.
+type verifyReader struct {
+	r      io.Reader
+	hasher hash.Hash
+	want   v1.Hash
+	size   int64 // size is the expected size, or -1 if unknown.
+	n      int64
+}
+
+// newVerifyReader returns a reader that reads from r, and returns an error in place of io.EOF if
+// the content read does not match digest h, or size if it is not -1. The error wraps
+// ErrBlobDigestMismatch or ErrBlobSizeMismatch respectively.
+func newVerifyReader(r io.Reader, h v1.Hash, size int64) (io.Reader, error) {
+	hasher, err := v1.Hasher(h.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifyReader{
+		r:      r,
+		hasher: hasher,
+		want:   h,
+		size:   size,
+	}, nil
+}
+
+func (vr *verifyReader) Read(p []byte) (int, error) {
+	n, err := vr.r.Read(p)
+	vr.hasher.Write(p[:n])
+	vr.n += int64(n)
+
+	if vr.size >= 0 && vr.n > vr.size {
+		return n, fmt.Errorf("%w: read more than %v bytes", ErrBlobSizeMismatch, vr.size)
+	}
+
+	if errors.Is(err, io.EOF) {
+		if vr.size >= 0 && vr.n != vr.size {
+			return n, fmt.Errorf("%w: got %v, want %v", ErrBlobSizeMismatch, vr.n, vr.size)
+		}
+
+		got := v1.Hash{
+			Algorithm: vr.want.Algorithm,
+			Hex:       hex.EncodeToString(vr.hasher.Sum(nil)),
+		}
+		if got != vr.want {
+			return n, fmt.Errorf("%w: got %v", ErrBlobDigestMismatch, got)
+		}
+	}
+
+	return n, err
+}
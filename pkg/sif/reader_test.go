@@ -0,0 +1,139 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	ggcrempty "github.com/google/go-containerregistry/pkg/v1/empty"
+	ggcrmutate "github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/sylabs/oci-tools/pkg/sif"
+)
+
+func Test_OCIFileImage_VerifyBlobs(t *testing.T) {
+	img, err := random.Image(64, 1, random.WithSource(rand.NewSource(randomSeed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readBlob := func(ofi *sif.OCIFileImage, opts ...sif.Option) error {
+		rc, err := ofi.Blob(firstLayer(t, ofi), opts...)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		_, err = io.Copy(io.Discard, rc)
+		return err
+	}
+
+	readBytes := func(ofi *sif.OCIFileImage, opts ...sif.Option) error {
+		_, err := ofi.Bytes(firstLayer(t, ofi), opts...)
+		return err
+	}
+
+	readLayer := func(ofi *sif.OCIFileImage, opts ...sif.Option) error {
+		img, err := ofi.Image(nil, opts...)
+		if err != nil {
+			return err
+		}
+		ls, err := img.Layers()
+		if err != nil {
+			return err
+		}
+
+		rc, err := ls[0].Compressed()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		_, err = io.Copy(io.Discard, rc)
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		read    func(*sif.OCIFileImage, ...sif.Option) error
+		corrupt bool
+		opts    []sif.Option
+		wantErr error
+	}{
+		{
+			name: "Blob",
+			read: readBlob,
+			opts: []sif.Option{sif.OptVerifyBlobs(true)},
+		},
+		{
+			name:    "BlobCorrupt",
+			read:    readBlob,
+			corrupt: true,
+		},
+		{
+			name:    "BlobCorruptVerify",
+			read:    readBlob,
+			corrupt: true,
+			opts:    []sif.Option{sif.OptVerifyBlobs(true)},
+			wantErr: sif.ErrBlobDigestMismatch,
+		},
+		{
+			name: "Bytes",
+			read: readBytes,
+			opts: []sif.Option{sif.OptVerifyBlobs(true)},
+		},
+		{
+			name:    "BytesCorruptVerify",
+			read:    readBytes,
+			corrupt: true,
+			opts:    []sif.Option{sif.OptVerifyBlobs(true)},
+			wantErr: sif.ErrBlobDigestMismatch,
+		},
+		{
+			name: "Layer",
+			read: readLayer,
+			opts: []sif.Option{sif.OptVerifyBlobs(true)},
+		},
+		{
+			name:    "LayerCorrupt",
+			read:    readLayer,
+			corrupt: true,
+		},
+		{
+			name:    "LayerCorruptVerify",
+			read:    readLayer,
+			corrupt: true,
+			opts:    []sif.Option{sif.OptVerifyBlobs(true)},
+			wantErr: sif.ErrBlobDigestMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "image.sif")
+			ii := ggcrmutate.AppendManifests(ggcrempty.Index, ggcrmutate.IndexAddendum{Add: img})
+			if err := sif.Write(path, ii); err != nil {
+				t.Fatal(err)
+			}
+
+			ofi, err := sif.LoadFromPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = ofi.Unload() })
+
+			if tt.corrupt {
+				corruptBlob(t, path, ofi, firstLayer(t, ofi))
+			}
+
+			if got, want := tt.read(ofi, tt.opts...), tt.wantErr; !errors.Is(got, want) {
+				t.Errorf("got error %v, want %v", got, want)
+			}
+		})
+	}
+}
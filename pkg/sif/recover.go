@@ -0,0 +1,117 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/sylabs/sif/v2/pkg/sif"
+)
+
+var errNoCompleteRootIndex = errors.New("no RootIndex with complete content found")
+
+// Recover repairs a SIF that was left in an inconsistent state by an interrupted update, such as
+// one applied with OptUpdateAtomic.
+//
+// Of the RootIndex objects present in the SIF, the most recently written that references only
+// blobs present in the SIF is retained. All other RootIndex objects, and any blobs that it does
+// not reference, are removed. If no such RootIndex is present, an error is returned and the SIF is
+// not modified.
+func (f *OCIFileImage) Recover() error {
+	ds, err := f.sif.GetDescriptors(sif.WithDataType(sif.DataOCIRootIndex))
+	if err != nil {
+		return err
+	}
+
+	// Consider the most recently written RootIndex first. Data objects are always appended, but
+	// descriptor slots are reused, so order by offset rather than ID.
+	slices.SortFunc(ds, func(a, b sif.Descriptor) int {
+		return cmp.Compare(b.Offset(), a.Offset())
+	})
+
+	for _, d := range ds {
+		ii, err := f.indexFromDescriptor(d)
+		if err != nil {
+			continue
+		}
+
+		keep := make(map[v1.Hash]bool)
+		if err := f.indexBlobs(ii, keep); err != nil {
+			continue
+		}
+
+		err = f.sif.DeleteObjects(
+			func(od sif.Descriptor) (bool, error) {
+				if od.DataType() == sif.DataOCIRootIndex {
+					return od.ID() != d.ID(), nil
+				}
+				if h, err := od.OCIBlobDigest(); err == nil && !keep[h] {
+					return true, nil
+				}
+				return false, nil
+			},
+			sif.OptDeleteZero(true),
+			sif.OptDeleteCompact(true),
+		)
+		if errors.Is(err, sif.ErrObjectNotFound) {
+			// The SIF is already consistent.
+			return nil
+		}
+		return err
+	}
+
+	return errNoCompleteRootIndex
+}
+
+var errBlobNotFound = errors.New("blob not found")
+
+// indexBlobs adds the digests of all blobs referenced by ii, and its child indexes and images, to
+// blobs. An error is returned if any referenced blob is not present in f.
+func (f *OCIFileImage) indexBlobs(ii v1.ImageIndex, blobs map[v1.Hash]bool) error {
+	im, err := ii.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	for _, desc := range im.Manifests {
+		if !f.hasBlob(desc.Digest) {
+			return fmt.Errorf("%w: %v", errBlobNotFound, desc.Digest)
+		}
+		blobs[desc.Digest] = true
+
+		switch mt := desc.MediaType; {
+		case mt.IsIndex():
+			child, err := ii.ImageIndex(desc.Digest)
+			if err != nil {
+				return err
+			}
+			if err := f.indexBlobs(child, blobs); err != nil {
+				return err
+			}
+
+		case mt.IsImage():
+			img, err := ii.Image(desc.Digest)
+			if err != nil {
+				return err
+			}
+			m, err := img.Manifest()
+			if err != nil {
+				return err
+			}
+			for _, ld := range append([]v1.Descriptor{m.Config}, m.Layers...) {
+				if !f.hasBlob(ld.Digest) {
+					return fmt.Errorf("%w: %v", errBlobNotFound, ld.Digest)
+				}
+				blobs[ld.Digest] = true
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	v1mutate "github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/sylabs/oci-tools/pkg/sif"
+	ssif "github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// addObject adds an object of type dt, holding b, to fi.
+func addObject(t *testing.T, fi *ssif.FileImage, dt ssif.DataType, b []byte) {
+	t.Helper()
+
+	di, err := ssif.NewDescriptorInput(dt, bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fi.AddObject(di); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// addImageBlobs adds the manifest, config and layers of img to fi as blobs.
+func addImageBlobs(t *testing.T, fi *ssif.FileImage, img v1.Image) {
+	t.Helper()
+
+	ls, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range ls {
+		rc, err := l.Compressed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		di, err := ssif.NewDescriptorInput(ssif.DataOCIBlob, rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fi.AddObject(di); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := img.RawConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addObject(t, fi, ssif.DataOCIBlob, cfg)
+
+	rm, err := img.RawManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addObject(t, fi, ssif.DataOCIBlob, rm)
+}
+
+func Test_OCIFileImage_Recover(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+	img, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		interrupt     func(*testing.T, *ssif.FileImage, v1.ImageIndex)
+		wantErr       bool
+		wantBlobs     int
+		wantManifests int
+	}{
+		{
+			name:          "Consistent",
+			interrupt:     func(*testing.T, *ssif.FileImage, v1.ImageIndex) {},
+			wantBlobs:     3,
+			wantManifests: 1,
+		},
+		{
+			name: "OrphanBlobs",
+			interrupt: func(t *testing.T, fi *ssif.FileImage, _ v1.ImageIndex) {
+				addImageBlobs(t, fi, img)
+			},
+			wantBlobs:     3,
+			wantManifests: 1,
+		},
+		{
+			name: "NewRootIndexComplete",
+			interrupt: func(t *testing.T, fi *ssif.FileImage, ri v1.ImageIndex) {
+				addImageBlobs(t, fi, img)
+				b, err := v1mutate.AppendManifests(ri, v1mutate.IndexAddendum{Add: img}).RawManifest()
+				if err != nil {
+					t.Fatal(err)
+				}
+				addObject(t, fi, ssif.DataOCIRootIndex, b)
+			},
+			wantBlobs:     6,
+			wantManifests: 2,
+		},
+		{
+			name: "NewRootIndexIncomplete",
+			interrupt: func(t *testing.T, fi *ssif.FileImage, ri v1.ImageIndex) {
+				b, err := v1mutate.AppendManifests(ri, v1mutate.IndexAddendum{Add: img}).RawManifest()
+				if err != nil {
+					t.Fatal(err)
+				}
+				addObject(t, fi, ssif.DataOCIRootIndex, b)
+			},
+			wantBlobs:     3,
+			wantManifests: 1,
+		},
+		{
+			name: "NewRootIndexLowerID",
+			interrupt: func(t *testing.T, fi *ssif.FileImage, ri v1.ImageIndex) {
+				old, err := ri.RawManifest()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Move the existing RootIndex to a higher descriptor slot, freeing a lower one.
+				addObject(t, fi, ssif.DataGeneric, []byte("generic"))
+				if err := fi.DeleteObjects(ssif.WithDataType(ssif.DataOCIRootIndex)); err != nil {
+					t.Fatal(err)
+				}
+				addObject(t, fi, ssif.DataGeneric, []byte("placeholder"))
+				addObject(t, fi, ssif.DataOCIRootIndex, old)
+				if err := fi.DeleteObjects(func(d ssif.Descriptor) (bool, error) {
+					b, err := d.GetData()
+					return string(b) == "placeholder", err
+				}); err != nil {
+					t.Fatal(err)
+				}
+
+				// The new RootIndex reuses the lower slot, but is written after the existing one.
+				b, err := v1mutate.RemoveManifests(ri, func(v1.Descriptor) bool { return true }).RawManifest()
+				if err != nil {
+					t.Fatal(err)
+				}
+				addObject(t, fi, ssif.DataOCIRootIndex, b)
+			},
+			wantBlobs:     0,
+			wantManifests: 0,
+		},
+		{
+			name: "NewRootIndexTorn",
+			interrupt: func(t *testing.T, fi *ssif.FileImage, ri v1.ImageIndex) {
+				addImageBlobs(t, fi, img)
+				b, err := v1mutate.AppendManifests(ri, v1mutate.IndexAddendum{Add: img}).RawManifest()
+				if err != nil {
+					t.Fatal(err)
+				}
+				addObject(t, fi, ssif.DataOCIRootIndex, b[:len(b)/2])
+			},
+			wantBlobs:     3,
+			wantManifests: 1,
+		},
+		{
+			name: "NoRootIndex",
+			interrupt: func(t *testing.T, fi *ssif.FileImage, _ v1.ImageIndex) {
+				if err := fi.DeleteObjects(ssif.WithDataType(ssif.DataOCIRootIndex)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sifPath := corpus.SIF(t, "hello-world-docker-v2-manifest", sif.OptWriteWithSpareDescriptorCapacity(8))
+			fi, err := ssif.LoadContainerFromPath(sifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			ofi, err := sif.FromFileImage(fi)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ri, err := ofi.RootIndex()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.interrupt(t, fi, ri)
+
+			err = ofi.Recover()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Recover() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got, want := numRootIndexes(t, fi), 1; got != want {
+				t.Errorf("got %v RootIndex objects, want %v", got, want)
+			}
+
+			ds, err := fi.GetDescriptors(ssif.WithDataType(ssif.DataOCIBlob))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(ds), tt.wantBlobs; got != want {
+				t.Errorf("got %v blobs, want %v", got, want)
+			}
+
+			ms, err := ofi.FindManifests(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(ms), tt.wantManifests; got != want {
+				t.Errorf("got %v manifests, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	"bytes"
+	"encoding/json"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// referrerManifest holds the fields of an image or index manifest that are
+// required to determine whether, and how, it refers to another manifest.
+type referrerManifest struct {
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Config       *v1.Descriptor    `json:"config,omitempty"`
+	Manifests    []v1.Descriptor   `json:"manifests,omitempty"`
+	Subject      *v1.Descriptor    `json:"subject,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// Referrers returns an ImageIndex holding a descriptor for each manifest stored in f that has a
+// subject with digest h, and that is selected by m. If m is nil, all referrers are selected.
+// Manifests referenced from the RootIndex, and from any index stored in f, are considered.
+//
+// As in the OCI distribution referrers API, each descriptor holds the artifactType and annotations
+// of the referring manifest. If an image manifest does not specify an artifactType, the media type
+// of its config is used.
+func (f *OCIFileImage) Referrers(h v1.Hash, m match.Matcher) (v1.ImageIndex, error) {
+	ri, err := f.RootIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	im, err := ri.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	m = matchAllIfNil(m)
+
+	referrers := []v1.Descriptor{}
+	seen := make(map[v1.Hash]bool)
+
+	for queue := im.Manifests; len(queue) > 0; {
+		desc := queue[0]
+		queue = queue[1:]
+
+		if seen[desc.Digest] || !(desc.MediaType.IsImage() || desc.MediaType.IsIndex()) {
+			continue
+		}
+		seen[desc.Digest] = true
+
+		b, err := f.Bytes(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		var rm referrerManifest
+		if err := json.Unmarshal(b, &rm); err != nil {
+			return nil, err
+		}
+
+		// Manifests referenced by an index may themselves be referrers.
+		queue = append(queue, rm.Manifests...)
+
+		if rm.Subject == nil || rm.Subject.Digest != h {
+			continue
+		}
+
+		rd := v1.Descriptor{
+			MediaType:    desc.MediaType,
+			Size:         desc.Size,
+			Digest:       desc.Digest,
+			ArtifactType: rm.ArtifactType,
+			Annotations:  rm.Annotations,
+		}
+		if rd.ArtifactType == "" && rm.Config != nil {
+			rd.ArtifactType = string(rm.Config.MediaType)
+		}
+
+		if m(rd) {
+			referrers = append(referrers, rd)
+		}
+	}
+
+	b, err := json.Marshal(v1.IndexManifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIImageIndex,
+		Manifests:     referrers,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digest, size, err := v1.SHA256(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	return &imageIndex{
+		f: f,
+		desc: &v1.Descriptor{
+			MediaType: types.OCIImageIndex,
+			Size:      size,
+			Digest:    digest,
+		},
+		rawManifest: b,
+	}, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"math/rand"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	v1mutate "github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/sylabs/oci-tools/pkg/sif"
+	ssif "github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// artifact returns a random OCI image, with the specified config media type.
+func artifact(t *testing.T, r rand.Source, mt types.MediaType) v1.Image {
+	t.Helper()
+
+	img, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img = v1mutate.MediaType(img, types.OCIManifestSchema1)
+	return v1mutate.ConfigMediaType(img, mt)
+}
+
+func Test_OCIFileImage_Referrers(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+
+	sifPath := corpus.SIF(t, "hello-world-docker-v2-manifest", sif.OptWriteWithSpareDescriptorCapacity(16))
+	fi, err := ssif.LoadContainerFromPath(sifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+	ofi, err := sif.FromFileImage(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := ofi.FindManifests(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject := ds[0]
+
+	sbom := artifact(t, r, "application/vnd.example.sbom")
+	if err := ofi.AppendImage(sbom, sif.OptAppendSubject(subject)); err != nil {
+		t.Fatal(err)
+	}
+	sig := artifact(t, r, "application/vnd.example.sig")
+	if err := ofi.AppendImage(sig, sif.OptAppendSubject(subject)); err != nil {
+		t.Fatal(err)
+	}
+	other := artifact(t, r, "application/vnd.example.sbom")
+	if err := ofi.AppendImage(other); err != nil {
+		t.Fatal(err)
+	}
+
+	// A subject cannot be set on an image with a Docker media type.
+	docker, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ofi.AppendImage(docker, sif.OptAppendSubject(subject)); err == nil {
+		t.Error("AppendImage() with Docker media type and subject, expected error")
+	}
+
+	otherDigest, err := other.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name              string
+		h                 v1.Hash
+		m                 match.Matcher
+		wantArtifactTypes []string
+	}{
+		{
+			name:              "All",
+			h:                 subject.Digest,
+			wantArtifactTypes: []string{"application/vnd.example.sbom", "application/vnd.example.sig"},
+		},
+		{
+			name:              "ArtifactType",
+			h:                 subject.Digest,
+			m:                 sif.ArtifactType("application/vnd.example.sig"),
+			wantArtifactTypes: []string{"application/vnd.example.sig"},
+		},
+		{
+			name:              "NoReferrers",
+			h:                 otherDigest,
+			wantArtifactTypes: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ii, err := ofi.Referrers(tt.h, tt.m)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			im, err := ii.IndexManifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := im.MediaType, types.OCIImageIndex; got != want {
+				t.Errorf("got media type %v, want %v", got, want)
+			}
+
+			if got, want := len(im.Manifests), len(tt.wantArtifactTypes); got != want {
+				t.Fatalf("got %v referrers, want %v", got, want)
+			}
+			for i, desc := range im.Manifests {
+				if got, want := desc.ArtifactType, tt.wantArtifactTypes[i]; got != want {
+					t.Errorf("got artifact type %v, want %v", got, want)
+				}
+
+				img, err := ii.Image(desc.Digest)
+				if err != nil {
+					t.Fatal(err)
+				}
+				m, err := img.Manifest()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if m.Subject == nil || m.Subject.Digest != tt.h {
+					t.Errorf("got subject %v, want %v", m.Subject, tt.h)
+				}
+			}
+		})
+	}
+}
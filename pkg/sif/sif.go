@@ -1,10 +1,12 @@
-// Copyright 2023 Sylabs Inc. All rights reserved.
+// Copyright 2023-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
 package sif
 
 import (
+	"errors"
+	"fmt"
 	"io"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
@@ -17,6 +19,8 @@ import (
 // artifacts.
 type OCIFileImage struct {
 	sif *sif.FileImage
+	// path is set if f was loaded from a path, and therefore owns sif.
+	path string
 }
 
 // FromFileImage constructs an extended oci-tools OCIFileImage, with OCI
@@ -26,24 +30,108 @@ func FromFileImage(fi *sif.FileImage) (*OCIFileImage, error) {
 	return f, nil
 }
 
-// Blob returns a ReadCloser that reads the blob with the supplied digest.
-func (f *OCIFileImage) Blob(h v1.Hash) (io.ReadCloser, error) {
+// LoadFromPath loads the SIF at path, and constructs an OCIFileImage from it.
+// Unlike an OCIFileImage constructed by FromFileImage, the descriptor capacity
+// of the SIF is grown automatically when required by an update. The caller
+// must call Unload when the OCIFileImage is no longer required.
+func LoadFromPath(path string) (*OCIFileImage, error) {
+	fi, err := sif.LoadContainerFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OCIFileImage{sif: fi, path: path}, nil
+}
+
+// Unload unloads the SIF loaded by LoadFromPath. It has no effect if f was
+// constructed by FromFileImage.
+func (f *OCIFileImage) Unload() error {
+	if f.path == "" {
+		return nil
+	}
+	return f.sif.UnloadContainer()
+}
+
+// Blob returns a ReadCloser that reads the blob with the supplied digest. To verify the content
+// of the blob as it is read, consider using OptVerifyBlobs.
+func (f *OCIFileImage) Blob(h v1.Hash, opts ...Option) (io.ReadCloser, error) {
+	o, err := getOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.blob(h, -1, o)
+}
+
+// blob returns a ReadCloser that reads the blob with the supplied digest. If o specifies that
+// blobs are verified, the content is checked against h, and size if it is not -1.
+func (f *OCIFileImage) blob(h v1.Hash, size int64, o options) (io.ReadCloser, error) {
 	d, err := f.sif.GetDescriptor(sif.WithOCIBlobDigest(h))
 	if err != nil {
 		return nil, err
 	}
 
-	return io.NopCloser(d.GetReader()), nil
+	if !o.verifyBlobs {
+		return io.NopCloser(d.GetReader()), nil
+	}
+
+	r, err := newVerifyReader(d.GetReader(), h, size)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(r), nil
 }
 
-// Bytes returns the bytes of the blob with the supplied digest.
-func (f *OCIFileImage) Bytes(h v1.Hash) ([]byte, error) {
+var errRandomAccessUnsupported = errors.New("random access to blob not supported")
+
+// BlobReaderAt returns a SectionReader that provides random access to the blob with the supplied
+// digest, such as to mount or seek within a squashfs layer, without copying it out of the SIF.
+// Offsets are relative to the start of the blob. The SectionReader remains valid until the SIF is
+// modified or unloaded.
+func (f *OCIFileImage) BlobReaderAt(h v1.Hash) (*io.SectionReader, error) {
 	d, err := f.sif.GetDescriptor(sif.WithOCIBlobDigest(h))
 	if err != nil {
 		return nil, err
 	}
 
-	return d.GetData()
+	// The reader returned by GetReader is a SectionReader over the SIF.
+	sr, ok := d.GetReader().(*io.SectionReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", errRandomAccessUnsupported, h)
+	}
+	return sr, nil
+}
+
+// Bytes returns the bytes of the blob with the supplied digest. To verify the content of the
+// blob, consider using OptVerifyBlobs.
+func (f *OCIFileImage) Bytes(h v1.Hash, opts ...Option) ([]byte, error) {
+	o, err := getOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.bytes(h, -1, o)
+}
+
+// bytes returns the bytes of the blob with the supplied digest. If o specifies that blobs are
+// verified, the content is checked against h, and size if it is not -1.
+func (f *OCIFileImage) bytes(h v1.Hash, size int64, o options) ([]byte, error) {
+	if !o.verifyBlobs {
+		d, err := f.sif.GetDescriptor(sif.WithOCIBlobDigest(h))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.GetData()
+	}
+
+	rc, err := f.blob(h, size, o)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
 }
 
 // Offset returns the offset within the SIF image of the blob with the supplied digest.
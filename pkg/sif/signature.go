@@ -0,0 +1,100 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	"errors"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// ociObject identifies an OCI.RootIndex/OCI.Blob object in a SIF. Object IDs are re-used as
+// objects are removed and added, so the digest is required to identify an object uniquely.
+type ociObject struct {
+	id     uint32
+	digest v1.Hash
+}
+
+// ociObjects returns the OCI.RootIndex/OCI.Blob objects in f, mapped to their group IDs.
+func (f *OCIFileImage) ociObjects() (map[ociObject]uint32, error) {
+	objects := make(map[ociObject]uint32)
+
+	ds, err := f.sif.GetDescriptors()
+	if errors.Is(err, sif.ErrNoObjects) {
+		return objects, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, d := range ds {
+		if h, err := d.OCIBlobDigest(); err == nil {
+			objects[ociObject{d.ID(), h}] = d.GroupID()
+		}
+	}
+
+	return objects, nil
+}
+
+// stripSignatures removes SIF signature objects from f that are invalidated by an update, given
+// the OCI objects that were present before the update. A signature is invalidated if it is linked
+// to an OCI object that was removed, or to an object group in which an OCI object was removed or
+// added. If fn is not nil, it is called with each signature object before it is removed.
+func (f *OCIFileImage) stripSignatures(before map[ociObject]uint32, fn func(sif.Descriptor)) error {
+	after, err := f.ociObjects()
+	if err != nil {
+		return err
+	}
+
+	removedIDs := make(map[uint32]bool)
+	changedGroups := make(map[uint32]bool)
+
+	for o, g := range before {
+		if _, ok := after[o]; !ok {
+			removedIDs[o.id] = true
+			changedGroups[g] = true
+		}
+	}
+	for o, g := range after {
+		if _, ok := before[o]; !ok {
+			changedGroups[g] = true
+		}
+	}
+
+	invalidated := func(d sif.Descriptor) bool {
+		if d.DataType() != sif.DataSignature {
+			return false
+		}
+		id, isGroup := d.LinkedID()
+		if isGroup {
+			return changedGroups[id]
+		}
+		return removedIDs[id]
+	}
+
+	ds, err := f.sif.GetDescriptors(func(d sif.Descriptor) (bool, error) {
+		return invalidated(d), nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(ds) == 0 {
+		return nil
+	}
+
+	if fn != nil {
+		for _, d := range ds {
+			fn(d)
+		}
+	}
+
+	return f.sif.DeleteObjects(
+		func(d sif.Descriptor) (bool, error) {
+			return invalidated(d), nil
+		},
+		sif.OptDeleteZero(true),
+		sif.OptDeleteCompact(true),
+	)
+}
@@ -0,0 +1,216 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"bytes"
+	"crypto"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/sylabs/oci-tools/pkg/sif"
+	ssif "github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// addSignature adds a signature object with the specified name to fi, linked via opt.
+func addSignature(t *testing.T, fi *ssif.FileImage, name string, opt ssif.DescriptorInputOpt) {
+	t.Helper()
+
+	di, err := ssif.NewDescriptorInput(ssif.DataSignature, bytes.NewReader([]byte(name)),
+		ssif.OptObjectName(name),
+		ssif.OptNoGroup(),
+		opt,
+		ssif.OptSignatureMetadata(crypto.SHA256, []byte("fingerprint")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fi.AddObject(di); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// addOCISignatures adds signature objects to the SIF at path, linked to the RootIndex, to the
+// first manifest in the RootIndex, and to the group holding OCI blobs.
+func addOCISignatures(t *testing.T, path string) {
+	t.Helper()
+
+	fi, err := ssif.LoadContainerFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = fi.UnloadContainer() }()
+
+	ofi, err := sif.FromFileImage(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ri, err := ofi.RootIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	im, err := ri.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rd, err := fi.GetDescriptor(ssif.WithDataType(ssif.DataOCIRootIndex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := fi.GetDescriptor(ssif.WithOCIBlobDigest(im.Manifests[0].Digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addSignature(t, fi, "root-index", ssif.OptLinkedID(rd.ID()))
+	addSignature(t, fi, "manifest", ssif.OptLinkedID(md.ID()))
+	addSignature(t, fi, "group", ssif.OptLinkedGroupID(md.GroupID()))
+}
+
+// signatureNames returns the names of the signature objects in fi, in sorted order.
+func signatureNames(t *testing.T, fi *ssif.FileImage) []string {
+	t.Helper()
+
+	ds, err := fi.GetDescriptors(ssif.WithDataType(ssif.DataSignature))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(ds))
+	for _, d := range ds {
+		names = append(names, d.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestUpdateStripSignatures(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+	img, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		update       func(*sif.OCIFileImage, func(ssif.Descriptor)) error
+		wantStripped []string
+		wantKept     []string
+	}{
+		{
+			name: "RemoveManifestsRetain",
+			update: func(ofi *sif.OCIFileImage, _ func(ssif.Descriptor)) error {
+				return ofi.RemoveManifests(nil)
+			},
+			wantKept: []string{"group", "manifest", "root-index", "signature"},
+		},
+		{
+			name: "RemoveManifests",
+			update: func(ofi *sif.OCIFileImage, fn func(ssif.Descriptor)) error {
+				return ofi.RemoveManifests(nil, sif.OptUpdateStripSignatures(fn))
+			},
+			wantStripped: []string{"group", "manifest", "root-index"},
+			wantKept:     []string{"signature"},
+		},
+		{
+			name: "RemoveManifestsAtomic",
+			update: func(ofi *sif.OCIFileImage, fn func(ssif.Descriptor)) error {
+				return ofi.RemoveManifests(nil, sif.OptUpdateStripSignatures(fn), sif.OptUpdateAtomic(true))
+			},
+			wantStripped: []string{"group", "manifest", "root-index"},
+			wantKept:     []string{"signature"},
+		},
+		{
+			name: "AppendImage",
+			update: func(ofi *sif.OCIFileImage, fn func(ssif.Descriptor)) error {
+				return ofi.AppendImage(img, sif.OptAppendStripSignatures(fn))
+			},
+			wantStripped: []string{"group", "root-index"},
+			wantKept:     []string{"manifest", "signature"},
+		},
+		{
+			name: "AppendImageAtomic",
+			update: func(ofi *sif.OCIFileImage, fn func(ssif.Descriptor)) error {
+				return ofi.AppendImage(img, sif.OptAppendStripSignatures(fn), sif.OptAppendAtomic(true))
+			},
+			wantStripped: []string{"group", "root-index"},
+			wantKept:     []string{"manifest", "signature"},
+		},
+		{
+			name: "ReplaceImage",
+			update: func(ofi *sif.OCIFileImage, fn func(ssif.Descriptor)) error {
+				return ofi.ReplaceImage(img, nil, sif.OptAppendStripSignatures(fn))
+			},
+			wantStripped: []string{"group", "manifest", "root-index"},
+			wantKept:     []string{"signature"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sifPath := corpus.SIF(t, "hello-world-docker-v2-manifest", sif.OptWriteWithSpareDescriptorCapacity(8))
+			addLinkedObjects(t, sifPath)
+			addOCISignatures(t, sifPath)
+
+			ofi, err := sif.LoadFromPath(sifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = ofi.Unload() })
+
+			var stripped []string
+			fn := func(d ssif.Descriptor) {
+				stripped = append(stripped, d.Name())
+			}
+
+			if err := tt.update(ofi, fn); err != nil {
+				t.Fatal(err)
+			}
+
+			slices.Sort(stripped)
+			if got, want := stripped, tt.wantStripped; !slices.Equal(got, want) {
+				t.Errorf("got stripped %v, want %v", got, want)
+			}
+
+			if err := ofi.Unload(); err != nil {
+				t.Fatal(err)
+			}
+
+			fi, err := ssif.LoadContainerFromPath(sifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			if got, want := signatureNames(t, fi), tt.wantKept; !slices.Equal(got, want) {
+				t.Errorf("got signatures %v, want %v", got, want)
+			}
+
+			d, err := fi.GetDescriptor(ssif.WithDataType(ssif.DataGeneric))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := d.Name(), "generic"; got != want {
+				t.Errorf("got name %q, want %q", got, want)
+			}
+
+			ofi, err = sif.FromFileImage(fi)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			vr, err := ofi.Verify()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := vr.Err(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
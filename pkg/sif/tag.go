@@ -0,0 +1,150 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Tag sets the reference of the manifest with digest h in the RootIndex of f to ref. The
+// reference is set as an `org.opencontainers.image.ref.name` annotation, and is removed from any
+// other manifest in the RootIndex. If the manifest already has a different reference, the
+// descriptor of the manifest is duplicated in the RootIndex, so that it holds both references.
+// If no manifest in the RootIndex has digest h, an error wrapping ErrNoMatch is returned.
+//
+// Only the RootIndex is rewritten. The SIF is updated as if by UpdateRootIndex, with the
+// specified opts.
+func (f *OCIFileImage) Tag(h v1.Hash, ref name.Reference, opts ...UpdateOpt) error {
+	ri, err := f.RootIndex()
+	if err != nil {
+		return err
+	}
+
+	im, err := ri.IndexManifest()
+	if err != nil {
+		return err
+	}
+	im = im.DeepCopy()
+
+	if !slices.ContainsFunc(im.Manifests, func(desc v1.Descriptor) bool { return desc.Digest == h }) {
+		return fmt.Errorf("%w: %v", ErrNoMatch, h)
+	}
+
+	// If the manifest already has the reference, there is nothing to do.
+	if slices.ContainsFunc(im.Manifests, func(desc v1.Descriptor) bool {
+		return desc.Digest == h && desc.Annotations[imagespec.AnnotationRefName] == ref.Name()
+	}) {
+		return nil
+	}
+
+	im.Manifests = untag(im.Manifests, ref.Name())
+
+	// Prefer a descriptor of the manifest that has no reference. Otherwise, duplicate the first.
+	i := slices.IndexFunc(im.Manifests, func(desc v1.Descriptor) bool {
+		_, ok := desc.Annotations[imagespec.AnnotationRefName]
+		return desc.Digest == h && !ok
+	})
+	if i < 0 {
+		i = slices.IndexFunc(im.Manifests, func(desc v1.Descriptor) bool { return desc.Digest == h })
+		desc := im.Manifests[i]
+		desc.Annotations = maps.Clone(desc.Annotations)
+		im.Manifests = append(im.Manifests, desc)
+		i = len(im.Manifests) - 1
+	}
+
+	if im.Manifests[i].Annotations == nil {
+		im.Manifests[i].Annotations = make(map[string]string)
+	}
+	im.Manifests[i].Annotations[imagespec.AnnotationRefName] = ref.Name()
+
+	return f.UpdateRootIndex(&editedManifest{base: ri, im: im}, opts...)
+}
+
+// Untag removes the reference ref from the RootIndex of f. If the manifest that ref refers to has
+// another descriptor in the RootIndex, the descriptor that held ref is removed. If no manifest in
+// the RootIndex has reference ref, an error wrapping ErrNoMatch is returned.
+//
+// Only the RootIndex is rewritten. The SIF is updated as if by UpdateRootIndex, with the
+// specified opts.
+func (f *OCIFileImage) Untag(ref name.Reference, opts ...UpdateOpt) error {
+	ri, err := f.RootIndex()
+	if err != nil {
+		return err
+	}
+
+	im, err := ri.IndexManifest()
+	if err != nil {
+		return err
+	}
+	im = im.DeepCopy()
+
+	hasRef := func(desc v1.Descriptor) bool {
+		return desc.Annotations[imagespec.AnnotationRefName] == ref.Name()
+	}
+	if !slices.ContainsFunc(im.Manifests, hasRef) {
+		return fmt.Errorf("%w: %v", ErrNoMatch, ref.Name())
+	}
+
+	im.Manifests = untag(im.Manifests, ref.Name())
+
+	return f.UpdateRootIndex(&editedManifest{base: ri, im: im}, opts...)
+}
+
+// untag removes the `org.opencontainers.image.ref.name` annotation with value refName from the
+// descriptors in ds. A descriptor is removed entirely if another descriptor in ds has the same
+// digest.
+func untag(ds []v1.Descriptor, refName string) []v1.Descriptor {
+	for i := 0; i < len(ds); i++ {
+		if ds[i].Annotations[imagespec.AnnotationRefName] != refName {
+			continue
+		}
+
+		h := ds[i].Digest
+		n := 0
+		for _, desc := range ds {
+			if desc.Digest == h {
+				n++
+			}
+		}
+
+		if n > 1 {
+			ds = slices.Delete(ds, i, i+1)
+			i--
+		} else {
+			delete(ds[i].Annotations, imagespec.AnnotationRefName)
+		}
+	}
+	return ds
+}
+
+// Tags returns the references set in the RootIndex of f, mapped to the digests of the manifests
+// they refer to. References are read from `org.opencontainers.image.ref.name` annotations, and
+// are returned as written, without parsing.
+func (f *OCIFileImage) Tags() (map[string]v1.Hash, error) {
+	ri, err := f.RootIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	im, err := ri.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]v1.Hash)
+	for _, desc := range im.Manifests {
+		if n, ok := desc.Annotations[imagespec.AnnotationRefName]; ok {
+			tags[n] = desc.Digest
+		}
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"errors"
+	"maps"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/sylabs/oci-tools/pkg/sif"
+	ssif "github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// blobIDs returns the OCI.Blob objects in fi, as a map of object ID to digest.
+func blobIDs(t *testing.T, fi *ssif.FileImage) map[uint32]v1.Hash {
+	t.Helper()
+
+	ds, err := fi.GetDescriptors(ssif.WithDataType(ssif.DataOCIBlob))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[uint32]v1.Hash, len(ds))
+	for _, d := range ds {
+		h, err := d.OCIBlobDigest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[d.ID()] = h
+	}
+	return ids
+}
+
+func Test_OCIFileImage_Tag(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+	img, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imgDigest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	img2, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img2Digest, err := img2.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imgRef := name.MustParseReference("myimage:v1", name.WithDefaultRegistry(""))
+	newRef := name.MustParseReference("myimage:v2", name.WithDefaultRegistry(""))
+
+	tests := []struct {
+		name     string
+		update   func(*sif.OCIFileImage) error
+		wantErr  error
+		wantTags map[string]v1.Hash
+	}{
+		{
+			name:     "None",
+			update:   func(*sif.OCIFileImage) error { return nil },
+			wantTags: map[string]v1.Hash{imgRef.Name(): imgDigest},
+		},
+		{
+			name:     "TagNew",
+			update:   func(ofi *sif.OCIFileImage) error { return ofi.Tag(img2Digest, newRef) },
+			wantTags: map[string]v1.Hash{imgRef.Name(): imgDigest, newRef.Name(): img2Digest},
+		},
+		{
+			name:     "TagMove",
+			update:   func(ofi *sif.OCIFileImage) error { return ofi.Tag(img2Digest, imgRef) },
+			wantTags: map[string]v1.Hash{imgRef.Name(): img2Digest},
+		},
+		{
+			name:     "TagExisting",
+			update:   func(ofi *sif.OCIFileImage) error { return ofi.Tag(imgDigest, imgRef) },
+			wantTags: map[string]v1.Hash{imgRef.Name(): imgDigest},
+		},
+		{
+			name: "TagAtomic",
+			update: func(ofi *sif.OCIFileImage) error {
+				return ofi.Tag(imgDigest, newRef, sif.OptUpdateAtomic(true))
+			},
+			wantTags: map[string]v1.Hash{imgRef.Name(): imgDigest, newRef.Name(): imgDigest},
+		},
+		{
+			name: "TagUntag",
+			update: func(ofi *sif.OCIFileImage) error {
+				if err := ofi.Tag(imgDigest, newRef); err != nil {
+					return err
+				}
+				return ofi.Untag(newRef)
+			},
+			wantTags: map[string]v1.Hash{imgRef.Name(): imgDigest},
+		},
+		{
+			name: "TagNotFound",
+			update: func(ofi *sif.OCIFileImage) error {
+				return ofi.Tag(v1.Hash{Algorithm: "sha256", Hex: "0000"}, newRef)
+			},
+			wantErr:  sif.ErrNoMatch,
+			wantTags: map[string]v1.Hash{imgRef.Name(): imgDigest},
+		},
+		{
+			name:     "Untag",
+			update:   func(ofi *sif.OCIFileImage) error { return ofi.Untag(imgRef) },
+			wantTags: map[string]v1.Hash{},
+		},
+		{
+			name:     "UntagNotFound",
+			update:   func(ofi *sif.OCIFileImage) error { return ofi.Untag(newRef) },
+			wantErr:  sif.ErrNoMatch,
+			wantTags: map[string]v1.Hash{imgRef.Name(): imgDigest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sifPath := filepath.Join(t.TempDir(), "test.sif")
+			if err := sif.Write(sifPath, empty.Index, sif.OptWriteWithSpareDescriptorCapacity(16)); err != nil {
+				t.Fatal(err)
+			}
+
+			fi, err := ssif.LoadContainerFromPath(sifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			ofi, err := sif.FromFileImage(fi)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ofi.AppendImage(img, sif.OptAppendReference(imgRef)); err != nil {
+				t.Fatal(err)
+			}
+			if err := ofi.AppendImage(img2); err != nil {
+				t.Fatal(err)
+			}
+
+			before := blobIDs(t, fi)
+
+			if err := tt.update(ofi); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			tags, err := ofi.Tags()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := tags, tt.wantTags; !maps.Equal(got, want) {
+				t.Errorf("got tags %v, want %v", got, want)
+			}
+
+			// Blobs must not be rewritten.
+			if got, want := blobIDs(t, fi), before; !maps.Equal(got, want) {
+				t.Errorf("got blobs %v, want %v", got, want)
+			}
+
+			vr, err := ofi.Verify()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := vr.Err(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-// Copyright 2024 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -7,6 +7,7 @@ package sif
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"maps"
 	"os"
@@ -21,6 +22,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sylabs/sif/v2/pkg/sif"
+	"golang.org/x/sync/errgroup"
 )
 
 // updateOpts accumulates update options.
@@ -29,6 +31,29 @@ type updateOpts struct {
 	tempDir string
 	// cacheDir created inside tempDir
 	cacheDir string
+	// atomic is true if new content must be committed before old content is removed
+	atomic bool
+	// stream is true if new blobs are written directly from the source, rather than cached
+	stream bool
+	// streamBlobs holds functions that open the source of each new blob, when streaming
+	streamBlobs map[v1.Hash]blobOpener
+	// concurrency is the maximum number of layers cached concurrently
+	concurrency int
+	// growth determines the descriptor capacity of a grown SIF, or is nil if growth is disabled
+	growth GrowthPolicy
+	// stripSignatures is called with each invalidated signature object before it is removed, or
+	// is nil if signature objects are not removed
+	stripSignatures func(sif.Descriptor)
+}
+
+// blobOpener returns a ReadCloser that reads the content of a blob.
+type blobOpener func() (io.ReadCloser, error)
+
+// bytesOpener returns a blobOpener that reads b.
+func bytesOpener(b []byte) blobOpener {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
 }
 
 // UpdateOpt are used to specify options to apply when updating a SIF.
@@ -43,18 +68,99 @@ func OptUpdateTempDir(d string) UpdateOpt {
 	}
 }
 
+// OptUpdateAtomic sets whether the update is applied atomically. By default, blobs that are no
+// longer referenced are removed from the SIF before new blobs and the new RootIndex are written,
+// which minimizes the descriptor capacity required, but leaves the SIF without a valid RootIndex
+// if the update is interrupted.
+//
+// When b is true, new blobs are written first, followed by the new RootIndex. Old content is only
+// removed once the new RootIndex has been committed. The SIF must have sufficient spare descriptor
+// capacity to hold the new blobs and RootIndex alongside the existing content. If the update is
+// interrupted, the SIF can be repaired with OCIFileImage.Recover.
+func OptUpdateAtomic(b bool) UpdateOpt {
+	return func(c *updateOpts) error {
+		c.atomic = b
+		return nil
+	}
+}
+
+// OptUpdateStream sets whether new blobs are streamed directly into the SIF. By default, each
+// blob that is referenced by the new ImageIndex, but not present in the SIF, is first cached in a
+// temporary directory, so that the ImageIndex is read in full before the SIF is modified.
+//
+// When b is true, new blobs are instead written directly into the SIF as they are read from the
+// ImageIndex, avoiding the temporary copy. The ImageIndex must remain readable while the SIF is
+// modified. This is the case if it is re-readable from a source other than the SIF, such as a
+// registry or OCI Image Layout, or if no blobs are removed from the SIF before new blobs are
+// written, as when appending, or when combined with OptUpdateAtomic.
+func OptUpdateStream(b bool) UpdateOpt {
+	return func(c *updateOpts) error {
+		c.stream = b
+		return nil
+	}
+}
+
+// OptUpdateConcurrency sets the maximum number of layers that are fetched, and cached,
+// concurrently. Regardless of n, new blobs are written to the SIF in the same order as if fetched
+// sequentially, so that the SIF is reproducible. By default, layers are fetched sequentially. The
+// option has no effect when streaming, as set by OptUpdateStream.
+func OptUpdateConcurrency(n int) UpdateOpt {
+	return func(c *updateOpts) error {
+		c.concurrency = n
+		return nil
+	}
+}
+
+// OptUpdateGrowth sets the policy used to grow the descriptor capacity of the SIF, when it is
+// insufficient to hold the update. Growth is only possible for an OCIFileImage loaded by
+// LoadFromPath. By default, GrowDouble is used. If p is nil, the SIF is not grown.
+func OptUpdateGrowth(p GrowthPolicy) UpdateOpt {
+	return func(c *updateOpts) error {
+		c.growth = p
+		return nil
+	}
+}
+
+// OptUpdateStripSignatures sets whether SIF signature objects that are invalidated by the update
+// are removed. A signature object is invalidated if it is linked to an OCI blob or RootIndex that
+// is removed, or to an object group in which OCI blobs are removed or added. By default, signature
+// objects are retained, even if the content they sign is removed.
+//
+// If fn is not nil, invalidated signature objects are removed, and fn is called with each one
+// before it is removed.
+func OptUpdateStripSignatures(fn func(sif.Descriptor)) UpdateOpt {
+	return func(c *updateOpts) error {
+		c.stripSignatures = fn
+		return nil
+	}
+}
+
 // UpdateRootIndex modifies the SIF file associated with f so that it holds the
 // content of ImageIndex ii. The RootIndex of the SIF is replaced with ii. Any
 // blobs in the SIF that are not referenced in ii are removed from the SIF. Any
 // blobs that are referenced in ii but not present in the SIF are added to the
-// SIF.
+// SIF. Descriptors in ii with a media type other than an image or index are
+// stored as opaque blobs, as with Write.
 //
 // UpdateRootIndex may create one or more temporary files during the update
 // process. By default, the directory returned by os.TempDir is used. To
-// override this, consider using OptUpdateTmpDir.
+// override this, consider using OptUpdateTmpDir. To avoid temporary files,
+// consider using OptUpdateStream.
+//
+// To apply the update atomically, consider using OptUpdateAtomic.
+//
+// If f was loaded by LoadFromPath, and the SIF has insufficient descriptor
+// capacity to hold the update, the SIF is first rewritten with a larger
+// descriptor capacity, preserving all existing objects. To override the
+// growth policy, consider using OptUpdateGrowth.
+//
+// Objects in the SIF other than OCI blobs and the RootIndex, such as
+// partitions and signatures, are retained. To remove signature objects
+// invalidated by the update, consider using OptUpdateStripSignatures.
 func (f *OCIFileImage) UpdateRootIndex(ii v1.ImageIndex, opts ...UpdateOpt) error {
 	uo := updateOpts{
 		tempDir: os.TempDir(),
+		growth:  GrowDouble,
 	}
 	for _, opt := range opts {
 		if err := opt(&uo); err != nil {
@@ -104,16 +210,38 @@ func (f *OCIFileImage) UpdateRootIndex(ii v1.ImageIndex, opts ...UpdateOpt) erro
 		return err
 	}
 
-	// Delete existing blobs from the SIF except those we want to keep.
-	if err := f.sif.DeleteObjects(selectBlobsExcept(keepBlobs),
-		sif.OptDeleteZero(true),
-		sif.OptDeleteCompact(true),
-	); err != nil {
+	// Grow the SIF, if required and possible.
+	if err := f.ensureCapacity(&uo, cachedBlobs, keepBlobs); err != nil {
 		return err
 	}
 
-	// Write new (cached) blobs from ii into the SIF.
+	// Record the OCI objects in the SIF, so that signature objects invalidated
+	// by the update can be identified. This follows growth, which renumbers
+	// objects.
+	var before map[ociObject]uint32
+	if uo.stripSignatures != nil {
+		if before, err = f.ociObjects(); err != nil {
+			return err
+		}
+	}
+
+	if !uo.atomic {
+		// Delete existing blobs from the SIF except those we want to keep.
+		if err := f.deleteBlobsExcept(keepBlobs); err != nil {
+			return err
+		}
+	}
+
+	// Write new (cached) blobs from ii into the SIF. A blob may be referenced
+	// more than once, such as an empty config shared by several artifacts, but
+	// is only written once.
+	written := make(map[v1.Hash]bool)
 	for _, b := range cachedBlobs {
+		if written[b] {
+			continue
+		}
+		written[b] = true
+
 		rc, err := uo.readCacheBlob(b)
 		if err != nil {
 			return err
@@ -127,7 +255,78 @@ func (f *OCIFileImage) UpdateRootIndex(ii v1.ImageIndex, opts ...UpdateOpt) erro
 	}
 
 	// Write the new RootIndex into the SIF.
-	return f.writeRootIndex(bytes.NewReader(ri))
+	if err := f.writeRootIndex(bytes.NewReader(ri)); err != nil {
+		return err
+	}
+
+	if uo.atomic {
+		// The new RootIndex is committed, so the old RootIndex and any blobs it
+		// alone referenced can now be removed.
+		err := f.deleteBlobsExcept(slices.Concat(keepBlobs, cachedBlobs, []v1.Hash{newRootDigest}))
+		if err != nil {
+			return err
+		}
+	}
+
+	if uo.stripSignatures == nil {
+		return nil
+	}
+	return f.stripSignatures(before, uo.stripSignatures)
+}
+
+// ensureCapacity grows the SIF associated with f, according to the growth
+// policy in uo, if it has insufficient descriptor capacity to hold the new
+// blobs in cached and a new RootIndex. The blobs not in keep are assumed to be
+// removed first, unless the update is atomic.
+func (f *OCIFileImage) ensureCapacity(uo *updateOpts, cached, keep []v1.Hash) error {
+	if f.path == "" || uo.growth == nil {
+		return nil
+	}
+
+	required, err := f.requiredDescriptors(uo.atomic, cached, keep)
+	if err != nil {
+		return err
+	}
+
+	total := f.sif.DescriptorsTotal()
+	if required <= total {
+		return nil
+	}
+
+	return f.grow(uo.growth(total, required))
+}
+
+// requiredDescriptors returns the descriptor capacity required to hold the
+// new blobs in cached and a new RootIndex, in addition to the existing
+// objects in the SIF. The blobs not in keep are assumed to be removed first,
+// unless atomic is true.
+func (f *OCIFileImage) requiredDescriptors(atomic bool, cached, keep []v1.Hash) (int64, error) {
+	// A blob may be referenced more than once, but is only written once.
+	unique := make(map[v1.Hash]bool)
+	for _, h := range cached {
+		unique[h] = true
+	}
+
+	required := f.sif.DescriptorsTotal() - f.sif.DescriptorsFree() + int64(len(unique)) + 1
+
+	if !atomic {
+		ds, err := f.sif.GetDescriptors(selectBlobsExcept(keep))
+		if err != nil {
+			return 0, err
+		}
+		required -= int64(len(ds))
+	}
+
+	return required, nil
+}
+
+// deleteBlobsExcept deletes all OCI.RootIndex/OCI.Blob descriptors from the
+// SIF, except those with digests listed in keep.
+func (f *OCIFileImage) deleteBlobsExcept(keep []v1.Hash) error {
+	return f.sif.DeleteObjects(selectBlobsExcept(keep),
+		sif.OptDeleteZero(true),
+		sif.OptDeleteCompact(true),
+	)
 }
 
 // Update is a convenience function, for backward compatibility, which calls
@@ -197,8 +396,7 @@ func (uo *updateOpts) cacheIndexBlobs(ii v1.ImageIndex, skip []v1.Hash) ([]v1.Ha
 			if err != nil {
 				return nil, nil, err
 			}
-			rc := io.NopCloser(bytes.NewReader(rm))
-			if err := uo.writeCacheBlob(rc, desc.Digest); err != nil {
+			if err := uo.writeCacheBlob(bytesOpener(rm), desc.Digest); err != nil {
 				return nil, nil, err
 			}
 			cached = append(cached, desc.Digest)
@@ -216,7 +414,19 @@ func (uo *updateOpts) cacheIndexBlobs(ii v1.ImageIndex, skip []v1.Hash) ([]v1.Ha
 			skipped = append(skipped, childSkipped...)
 
 		default:
-			return nil, nil, errUnexpectedMediaType
+			// Any other media type is stored as an opaque blob, such as an
+			// artifact that is not wrapped in an image manifest.
+			if slices.Contains(skip, desc.Digest) {
+				skipped = append(skipped, desc.Digest)
+				continue
+			}
+			open := func() (io.ReadCloser, error) {
+				return blobFromIndex(ii, desc.Digest)
+			}
+			if err := uo.writeCacheBlob(open, desc.Digest); err != nil {
+				return nil, nil, err
+			}
+			cached = append(cached, desc.Digest)
 		}
 	}
 	return cached, skipped, nil
@@ -236,6 +446,18 @@ func (uo *updateOpts) cacheImageBlobs(im v1.Image, skip []v1.Hash) ([]v1.Hash, [
 	if err != nil {
 		return nil, nil, err
 	}
+	// Layers may be cached concurrently, as the order in which they are
+	// written to the SIF is determined by cached, rather than completion order.
+	concurrent := uo.concurrency > 1 && !uo.stream
+	if concurrent {
+		if err := uo.makeCacheDir(); err != nil {
+			return nil, nil, err
+		}
+	}
+	var g errgroup.Group
+	g.SetLimit(max(uo.concurrency, 1))
+	queued := make(map[v1.Hash]bool)
+
 	for _, l := range layers {
 		ld, err := l.Digest()
 		if err != nil {
@@ -247,15 +469,19 @@ func (uo *updateOpts) cacheImageBlobs(im v1.Image, skip []v1.Hash) ([]v1.Hash, [
 			continue
 		}
 
-		rc, err := l.Compressed()
-		if err != nil {
-			return nil, nil, err
-		}
-		if err := uo.writeCacheBlob(rc, ld); err != nil {
-			return nil, nil, err
+		if !concurrent {
+			if err := uo.writeCacheBlob(l.Compressed, ld); err != nil {
+				return nil, nil, err
+			}
+		} else if !queued[ld] {
+			queued[ld] = true
+			g.Go(func() error { return uo.writeCacheBlob(l.Compressed, ld) })
 		}
 		cached = append(cached, ld)
 	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
 
 	// Cache image config.
 	mf, err := im.Manifest()
@@ -269,8 +495,7 @@ func (uo *updateOpts) cacheImageBlobs(im v1.Image, skip []v1.Hash) ([]v1.Hash, [
 		if err != nil {
 			return nil, nil, err
 		}
-		rc := io.NopCloser(bytes.NewReader(c))
-		if err := uo.writeCacheBlob(rc, mf.Config.Digest); err != nil {
+		if err := uo.writeCacheBlob(bytesOpener(c), mf.Config.Digest); err != nil {
 			return nil, nil, err
 		}
 		cached = append(cached, mf.Config.Digest)
@@ -289,8 +514,7 @@ func (uo *updateOpts) cacheImageBlobs(im v1.Image, skip []v1.Hash) ([]v1.Hash, [
 	if err != nil {
 		return nil, nil, err
 	}
-	rc := io.NopCloser(bytes.NewReader(rm))
-	if err := uo.writeCacheBlob(rc, id); err != nil {
+	if err := uo.writeCacheBlob(bytesOpener(rm), id); err != nil {
 		return nil, nil, err
 	}
 	cached = append(cached, id)
@@ -298,39 +522,60 @@ func (uo *updateOpts) cacheImageBlobs(im v1.Image, skip []v1.Hash) ([]v1.Hash, [
 	return cached, skipped, nil
 }
 
-// writeCacheBlob writes blob content from rc into a cache directory with
-// filename equal to specified digest.
-func (uo *updateOpts) writeCacheBlob(rc io.ReadCloser, digest v1.Hash) error {
-	if uo.cacheDir == "" {
-		var err error
-		if uo.cacheDir, err = os.MkdirTemp(uo.tempDir, ""); err != nil {
-			return err
+// makeCacheDir creates the cache directory inside tempDir, if it has not
+// already been created.
+func (uo *updateOpts) makeCacheDir() error {
+	if uo.cacheDir != "" {
+		return nil
+	}
+
+	var err error
+	uo.cacheDir, err = os.MkdirTemp(uo.tempDir, "")
+	return err
+}
+
+// writeCacheBlob writes blob content from open into a cache directory with
+// filename equal to specified digest. If streaming, open is recorded so that
+// the content can be read directly by readCacheBlob instead.
+func (uo *updateOpts) writeCacheBlob(open blobOpener, digest v1.Hash) error {
+	if uo.stream {
+		if uo.streamBlobs == nil {
+			uo.streamBlobs = make(map[v1.Hash]blobOpener)
 		}
+		uo.streamBlobs[digest] = open
+		return nil
 	}
 
-	path := filepath.Join(uo.cacheDir, digest.String())
-	f, err := os.Create(path)
+	rc, err := open()
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	defer rc.Close()
 
-	_, err = io.Copy(f, rc)
-	if err != nil {
+	if err := uo.makeCacheDir(); err != nil {
 		return err
 	}
 
-	if err := rc.Close(); err != nil {
+	path := filepath.Join(uo.cacheDir, digest.String())
+	f, err := os.Create(path)
+	if err != nil {
 		return err
 	}
-	return nil
+	defer f.Close()
+
+	_, err = io.Copy(f, rc)
+	return err
 }
 
 var errNoCacheDir = errors.New("cacheDir not set")
 
 // readCacheBlob returns a ReadCloser that will read blob content from the cache
-// directory with filename equal to specified digest.
+// directory with filename equal to specified digest. If streaming, the content
+// is read directly from its source.
 func (uo *updateOpts) readCacheBlob(digest v1.Hash) (io.ReadCloser, error) {
+	if open, ok := uo.streamBlobs[digest]; ok {
+		return open()
+	}
 	if uo.cacheDir == "" {
 		return nil, errNoCacheDir
 	}
@@ -355,8 +600,14 @@ func selectBlobsExcept(keep []v1.Hash) sif.DescriptorSelectorFunc {
 
 // appendOpts accumulates append options.
 type appendOpts struct {
-	tempDir string
-	ref     name.Reference
+	tempDir         string
+	ref             name.Reference
+	subject         *v1.Descriptor
+	atomic          bool
+	stream          bool
+	concurrency     int
+	growth          GrowthPolicy
+	stripSignatures func(sif.Descriptor)
 }
 
 // AppendOpt are used to specify options to apply when appending to a SIF.
@@ -371,6 +622,49 @@ func OptAppendTempDir(d string) AppendOpt {
 	}
 }
 
+// OptAppendAtomic sets whether the SIF is updated atomically. See OptUpdateAtomic.
+func OptAppendAtomic(b bool) AppendOpt {
+	return func(c *appendOpts) error {
+		c.atomic = b
+		return nil
+	}
+}
+
+// OptAppendStream sets whether new blobs are streamed directly into the SIF. See OptUpdateStream.
+func OptAppendStream(b bool) AppendOpt {
+	return func(c *appendOpts) error {
+		c.stream = b
+		return nil
+	}
+}
+
+// OptAppendConcurrency sets the maximum number of layers that are fetched concurrently. See
+// OptUpdateConcurrency.
+func OptAppendConcurrency(n int) AppendOpt {
+	return func(c *appendOpts) error {
+		c.concurrency = n
+		return nil
+	}
+}
+
+// OptAppendGrowth sets the policy used to grow the descriptor capacity of the SIF. See
+// OptUpdateGrowth.
+func OptAppendGrowth(p GrowthPolicy) AppendOpt {
+	return func(c *appendOpts) error {
+		c.growth = p
+		return nil
+	}
+}
+
+// OptAppendStripSignatures sets whether SIF signature objects that are invalidated are removed. See
+// OptUpdateStripSignatures.
+func OptAppendStripSignatures(fn func(sif.Descriptor)) AppendOpt {
+	return func(c *appendOpts) error {
+		c.stripSignatures = fn
+		return nil
+	}
+}
+
 // OptAppendReference sets the reference to be set for the appended item in the
 // RootIndex. The reference is added as an `org.opencontainers.image.ref.name`
 // in the RootIndex.
@@ -381,6 +675,17 @@ func OptAppendReference(r name.Reference) AppendOpt {
 	}
 }
 
+// OptAppendSubject sets the subject of the appended item to the manifest
+// described by d. The appended item is then returned as a referrer of that
+// manifest by OCIFileImage.Referrers. A subject may only be set on an item with
+// an OCI media type.
+func OptAppendSubject(d v1.Descriptor) AppendOpt {
+	return func(c *appendOpts) error {
+		c.subject = &d
+		return nil
+	}
+}
+
 // AppendImage appends an image to the SIF f, updating the RootIndex to
 // reference it.
 func (f *OCIFileImage) AppendImage(img v1.Image, opts ...AppendOpt) error {
@@ -393,9 +698,18 @@ func (f *OCIFileImage) AppendIndex(ii v1.ImageIndex, opts ...AppendOpt) error {
 	return f.append(ii, opts...)
 }
 
+// AppendBlob appends the blob l to the SIF f, updating the RootIndex to
+// reference it directly. This can be used to store an artifact that is not
+// wrapped in an image manifest, such as a Helm chart or WASM module, using a
+// layer constructed with static.NewLayer.
+func (f *OCIFileImage) AppendBlob(l v1.Layer, opts ...AppendOpt) error {
+	return f.append(l, opts...)
+}
+
 func (f *OCIFileImage) append(add mutate.Appendable, opts ...AppendOpt) error {
 	ao := appendOpts{
 		tempDir: os.TempDir(),
+		growth:  GrowDouble,
 	}
 	for _, opt := range opts {
 		if err := opt(&ao); err != nil {
@@ -413,10 +727,37 @@ func (f *OCIFileImage) append(add mutate.Appendable, opts ...AppendOpt) error {
 		return err
 	}
 
-	return f.UpdateRootIndex(ri, OptUpdateTempDir(ao.tempDir))
+	return f.UpdateRootIndex(ri,
+		OptUpdateTempDir(ao.tempDir),
+		OptUpdateAtomic(ao.atomic),
+		OptUpdateStream(ao.stream),
+		OptUpdateConcurrency(ao.concurrency),
+		OptUpdateGrowth(ao.growth),
+		OptUpdateStripSignatures(ao.stripSignatures),
+	)
 }
 
+var errSubjectMediaType = errors.New("subject cannot be set on item with media type")
+
 func appendToIndex(base v1.ImageIndex, add mutate.Appendable, ao appendOpts) (v1.ImageIndex, error) {
+	if ao.subject != nil {
+		mt, err := add.MediaType()
+		if err != nil {
+			return nil, err
+		}
+		if mt != types.OCIManifestSchema1 && mt != types.OCIImageIndex {
+			return nil, fmt.Errorf("%w: %v", errSubjectMediaType, mt)
+		}
+
+		rm, ok := add.(partial.WithRawManifest)
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", errSubjectMediaType, mt)
+		}
+		if add, ok = mutate.Subject(rm, *ao.subject).(mutate.Appendable); !ok {
+			return nil, fmt.Errorf("%w: %v", errSubjectMediaType, mt)
+		}
+	}
+
 	ia := mutate.IndexAddendum{Add: add}
 
 	var err error
@@ -455,6 +796,8 @@ func removeRefAnnotation(ii v1.ImageIndex, ref name.Reference) (v1.ImageIndex, e
 }
 
 // RemoveBlob removes a blob from the SIF f, without modifying the rootIndex.
+// No check is made that the blob is unreferenced. To remove only blobs that
+// are not referenced, consider using GarbageCollect.
 func (f *OCIFileImage) RemoveBlob(hash v1.Hash) error {
 	return f.sif.DeleteObjects(sif.WithOCIBlobDigest(hash),
 		sif.OptDeleteZero(true),
@@ -465,13 +808,15 @@ func (f *OCIFileImage) RemoveBlob(hash v1.Hash) error {
 // RootIndex no longer holds manifests selected by matcher. If m is nil, all
 // manifests are selected. Any blobs in the SIF that are no longer referenced
 // are removed from the SIF.
-func (f *OCIFileImage) RemoveManifests(matcher match.Matcher) error {
+//
+// The SIF is updated as if by UpdateRootIndex, with the specified opts.
+func (f *OCIFileImage) RemoveManifests(matcher match.Matcher, opts ...UpdateOpt) error {
 	ri, err := f.RootIndex()
 	if err != nil {
 		return err
 	}
 
-	return f.UpdateRootIndex(mutate.RemoveManifests(ri, matchAllIfNil(matcher)))
+	return f.UpdateRootIndex(mutate.RemoveManifests(ri, matchAllIfNil(matcher)), opts...)
 }
 
 // ReplaceImage writes img to the SIF, replacing any existing manifest that is
@@ -494,6 +839,7 @@ func (f *OCIFileImage) ReplaceIndex(ii v1.ImageIndex, matcher match.Matcher, opt
 func (f *OCIFileImage) replace(add mutate.Appendable, matcher match.Matcher, opts ...AppendOpt) error {
 	ao := appendOpts{
 		tempDir: os.TempDir(),
+		growth:  GrowDouble,
 	}
 	for _, opt := range opts {
 		if err := opt(&ao); err != nil {
@@ -513,5 +859,12 @@ func (f *OCIFileImage) replace(add mutate.Appendable, matcher match.Matcher, opt
 		return err
 	}
 
-	return f.UpdateRootIndex(ri, OptUpdateTempDir(ao.tempDir))
+	return f.UpdateRootIndex(ri,
+		OptUpdateTempDir(ao.tempDir),
+		OptUpdateAtomic(ao.atomic),
+		OptUpdateStream(ao.stream),
+		OptUpdateConcurrency(ao.concurrency),
+		OptUpdateGrowth(ao.growth),
+		OptUpdateStripSignatures(ao.stripSignatures),
+	)
 }
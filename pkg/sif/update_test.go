@@ -1,12 +1,15 @@
-// Copyright 2024 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
 package sif_test
 
 import (
+	"bytes"
+	"io"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/name"
@@ -15,6 +18,7 @@ import (
 	match "github.com/google/go-containerregistry/pkg/v1/match"
 	v1mutate "github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/static"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/sebdah/goldie/v2"
 	"github.com/sylabs/oci-tools/pkg/mutate"
@@ -548,3 +552,355 @@ func TestReplace(t *testing.T) {
 		})
 	}
 }
+
+// checkBlob verifies that the blob with digest h can be read from ofi, and holds want.
+func checkBlob(t *testing.T, ofi *sif.OCIFileImage, h v1.Hash, want []byte) {
+	t.Helper()
+
+	rc, err := ofi.Blob(h)
+	if err != nil {
+		t.Fatalf("Blob(%v) error = %v", h, err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got blob %q, want %q", got, want)
+	}
+}
+
+func TestArtifacts(t *testing.T) {
+	helmContent := []byte("helm chart")
+	helm := static.NewLayer(helmContent, "application/vnd.cncf.helm.chart.content.v1.tar+gzip")
+	wasmContent := []byte("\x00asm")
+	wasm := static.NewLayer(wasmContent, "application/vnd.wasm.content.layer.v1+wasm")
+	dataContent := []byte("data")
+	data := static.NewLayer(dataContent, "application/vnd.example.data")
+
+	// Artifact manifests with a custom artifactType, that share a config blob.
+	base := v1mutate.ConfigMediaType(
+		v1mutate.MediaType(empty.Image, types.OCIManifestSchema1),
+		"application/vnd.example.artifact",
+	)
+	artifact1, ok := v1mutate.Annotations(base, map[string]string{"artifact": "1"}).(v1.Image)
+	if !ok {
+		t.Fatal("unexpected artifact type")
+	}
+	artifact2, ok := v1mutate.Annotations(base, map[string]string{"artifact": "2"}).(v1.Image)
+	if !ok {
+		t.Fatal("unexpected artifact type")
+	}
+
+	ii := v1mutate.AppendManifests(empty.Index,
+		v1mutate.IndexAddendum{Add: helm},
+		v1mutate.IndexAddendum{Add: artifact1},
+		v1mutate.IndexAddendum{Add: artifact2},
+	)
+
+	sifPath := t.TempDir() + "/artifacts.sif"
+	if err := sif.Write(sifPath, ii, sif.OptWriteWithSpareDescriptorCapacity(4)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	fi, err := ssif.LoadContainerFromPath(sifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+	// RootIndex, helm blob, 2 artifact manifests and 1 shared config.
+	if got, want := fi.DescriptorsTotal(), int64(5+4); got != want {
+		t.Errorf("got %v descriptors, want %v", got, want)
+	}
+
+	ofi, err := sif.FromFileImage(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ofi.AppendBlob(wasm); err != nil {
+		t.Fatalf("AppendBlob() error = %v", err)
+	}
+
+	if err := ofi.AppendIndex(v1mutate.AppendManifests(empty.Index, v1mutate.IndexAddendum{Add: data})); err != nil {
+		t.Fatalf("AppendIndex() error = %v", err)
+	}
+
+	helmDigest, err := helm.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wasmDigest, err := wasm.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataDigest, err := data.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkBlob(t, ofi, helmDigest, helmContent)
+	checkBlob(t, ofi, wasmDigest, wasmContent)
+	checkBlob(t, ofi, dataDigest, dataContent)
+
+	for _, a := range []v1.Image{artifact1, artifact2} {
+		h, err := a.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := ofi.Image(match.Digests(h))
+		if err != nil {
+			t.Fatalf("Image(%v) error = %v", h, err)
+		}
+		if _, err := img.RawConfigFile(); err != nil {
+			t.Errorf("RawConfigFile() error = %v", err)
+		}
+	}
+
+	if err := ofi.RemoveManifests(match.Digests(helmDigest)); err != nil {
+		t.Fatalf("RemoveManifests() error = %v", err)
+	}
+
+	if _, err := ofi.Blob(helmDigest); err == nil {
+		t.Errorf("Blob(%v) expected error after removal", helmDigest)
+	}
+	checkBlob(t, ofi, wasmDigest, wasmContent)
+	checkBlob(t, ofi, dataDigest, dataContent)
+
+	// Wasm blob, data blob and index, 2 artifact manifests and 1 shared config.
+	ds, err := fi.GetDescriptors(ssif.WithDataType(ssif.DataOCIBlob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(ds), 6; got != want {
+		t.Errorf("got %v blobs, want %v", got, want)
+	}
+}
+
+// numRootIndexes returns the number of RootIndex objects in fi.
+func numRootIndexes(t *testing.T, fi *ssif.FileImage) int {
+	t.Helper()
+
+	ds, err := fi.GetDescriptors(ssif.WithDataType(ssif.DataOCIRootIndex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(ds)
+}
+
+func TestUpdateAtomic(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+	img, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		spare         int64
+		wantErr       bool
+		wantBlobs     int
+		wantManifests int
+	}{
+		{
+			name:          "Default",
+			spare:         8,
+			wantBlobs:     6,
+			wantManifests: 2,
+		},
+		{
+			// Atomic updates require capacity for the new RootIndex and blobs
+			// alongside the existing content.
+			name:          "InsufficientCapacity",
+			spare:         3,
+			wantErr:       true,
+			wantBlobs:     3,
+			wantManifests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sifPath := corpus.SIF(t, "hello-world-docker-v2-manifest", sif.OptWriteWithSpareDescriptorCapacity(tt.spare))
+			fi, err := ssif.LoadContainerFromPath(sifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			ofi, err := sif.FromFileImage(fi)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ofi.AppendImage(img, sif.OptAppendAtomic(true))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AppendImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				// Interrupted update leaves the original RootIndex valid, and
+				// can be recovered.
+				if err := ofi.Recover(); err != nil {
+					t.Fatalf("Recover() error = %v", err)
+				}
+			}
+
+			if got, want := numRootIndexes(t, fi), 1; got != want {
+				t.Errorf("got %v RootIndex objects, want %v", got, want)
+			}
+
+			ds, err := fi.GetDescriptors(ssif.WithDataType(ssif.DataOCIBlob))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(ds), tt.wantBlobs; got != want {
+				t.Errorf("got %v blobs, want %v", got, want)
+			}
+
+			ms, err := ofi.FindManifests(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(ms), tt.wantManifests; got != want {
+				t.Errorf("got %v manifests, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestUpdateStream(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+	img, err := random.Image(64, 1, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Caching blobs in this directory would fail, so the update must stream.
+	tempDir := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name          string
+		update        func(*sif.OCIFileImage) error
+		wantBlobs     int
+		wantManifests int
+	}{
+		{
+			name: "AppendImage",
+			update: func(ofi *sif.OCIFileImage) error {
+				return ofi.AppendImage(img, sif.OptAppendTempDir(tempDir), sif.OptAppendStream(true))
+			},
+			wantBlobs:     6,
+			wantManifests: 2,
+		},
+		{
+			name: "AppendImageAtomic",
+			update: func(ofi *sif.OCIFileImage) error {
+				return ofi.AppendImage(img,
+					sif.OptAppendTempDir(tempDir),
+					sif.OptAppendStream(true),
+					sif.OptAppendAtomic(true),
+				)
+			},
+			wantBlobs:     6,
+			wantManifests: 2,
+		},
+		{
+			name: "ReplaceRootIndex",
+			update: func(ofi *sif.OCIFileImage) error {
+				ii := corpus.ImageIndex(t, "hello-world-docker-v2-manifest-list")
+				return ofi.UpdateRootIndex(ii, sif.OptUpdateTempDir(tempDir), sif.OptUpdateStream(true))
+			},
+			wantBlobs:     27,
+			wantManifests: 9,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sifPath := corpus.SIF(t, "hello-world-docker-v2-manifest", sif.OptWriteWithSpareDescriptorCapacity(32))
+			fi, err := ssif.LoadContainerFromPath(sifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			ofi, err := sif.FromFileImage(fi)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.update(ofi); err != nil {
+				t.Fatal(err)
+			}
+
+			vr, err := ofi.Verify()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := vr.Err(); err != nil {
+				t.Error(err)
+			}
+
+			ds, err := fi.GetDescriptors(ssif.WithDataType(ssif.DataOCIBlob))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(ds), tt.wantBlobs; got != want {
+				t.Errorf("got %v blobs, want %v", got, want)
+			}
+
+			ms, err := ofi.FindManifests(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(ms), tt.wantManifests; got != want {
+				t.Errorf("got %v manifests, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestUpdateConcurrency(t *testing.T) {
+	r := rand.NewSource(randomSeed)
+	img, err := random.Image(64, 8, random.WithSource(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appendImage := func(t *testing.T, opts ...sif.AppendOpt) []byte {
+		t.Helper()
+
+		sifPath := corpus.SIF(t, "hello-world-docker-v2-manifest", sif.OptWriteWithSpareDescriptorCapacity(16))
+		fi, err := ssif.LoadContainerFromPath(sifPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ofi, err := sif.FromFileImage(fi)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ofi.AppendImage(img, opts...); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := fi.UnloadContainer(); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := os.ReadFile(sifPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	want := appendImage(t)
+
+	if got := appendImage(t, sif.OptAppendConcurrency(4)); !bytes.Equal(got, want) {
+		t.Error("concurrent update differs from sequential update")
+	}
+}
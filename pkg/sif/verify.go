@@ -0,0 +1,357 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/sylabs/sif/v2/pkg/sif"
+)
+
+var (
+	// ErrBlobMissing indicates that a blob referenced by the RootIndex is not present.
+	ErrBlobMissing = errors.New("blob missing")
+	// ErrBlobDigestMismatch indicates that the content of a blob does not match its digest.
+	ErrBlobDigestMismatch = errors.New("blob digest mismatch")
+	// ErrBlobSizeMismatch indicates that the size of a blob does not match its descriptor.
+	ErrBlobSizeMismatch = errors.New("blob size mismatch")
+	// ErrDiffIDMismatch indicates that an uncompressed layer does not match the diff_ids of its
+	// image config.
+	ErrDiffIDMismatch = errors.New("diff_id mismatch")
+	// ErrBlobOrphaned indicates that a blob is not referenced by the RootIndex.
+	ErrBlobOrphaned = errors.New("blob not referenced")
+	// ErrBlobDuplicated indicates that more than one object holds a blob with the same digest.
+	ErrBlobDuplicated = errors.New("blob duplicated")
+	// ErrBlobMalformed indicates that the content of a blob is intact, but cannot be parsed or
+	// decompressed.
+	ErrBlobMalformed = errors.New("blob malformed")
+)
+
+// Problem describes an inconsistency found in a SIF by OCIFileImage.Verify.
+type Problem struct {
+	// Digest is the digest of the blob that the problem relates to.
+	Digest v1.Hash
+	// Err describes the problem, and wraps one of ErrBlobMissing, ErrBlobDigestMismatch,
+	// ErrBlobSizeMismatch, ErrDiffIDMismatch, ErrBlobOrphaned, ErrBlobDuplicated or
+	// ErrBlobMalformed, if applicable.
+	Err error
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("%v: %v", p.Digest, p.Err)
+}
+
+func (p Problem) Unwrap() error {
+	return p.Err
+}
+
+// VerifyReport holds the result of OCIFileImage.Verify.
+type VerifyReport struct {
+	// Problems holds the problems found, in the order they were encountered.
+	Problems []Problem
+}
+
+// OK returns true if no problems were found.
+func (r *VerifyReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Err returns an error joining all problems found, or nil if no problems were found.
+func (r *VerifyReport) Err() error {
+	errs := make([]error, len(r.Problems))
+	for i, p := range r.Problems {
+		errs[i] = p
+	}
+	return errors.Join(errs...)
+}
+
+func (r *VerifyReport) add(h v1.Hash, err error) {
+	r.Problems = append(r.Problems, Problem{Digest: h, Err: err})
+}
+
+// verifier accumulates state while verifying a SIF.
+type verifier struct {
+	f      *OCIFileImage
+	report *VerifyReport
+	// intact records whether each blob examined so far is present and intact.
+	intact map[v1.Hash]bool
+	// visited records the digests of the indexes and manifests whose references have been
+	// verified, so that those referenced more than once are verified, and reported, once.
+	visited map[v1.Hash]bool
+}
+
+// Verify checks that the SIF associated with f is internally consistent. The RootIndex, and every
+// index and manifest that it references, is walked. Each referenced blob is checked to be present,
+// and its content is re-hashed and compared against the digest and size of its descriptor. The
+// uncompressed content of each image layer is checked against the rootfs.diff_ids of the image
+// config. Blobs that are not referenced, and blobs that are held by more than one object, are
+// also reported.
+//
+// Problems found are accumulated in the returned VerifyReport, rather than causing Verify to fail.
+// An error is returned only if the SIF cannot be examined, such as when the RootIndex is missing
+// or cannot be read.
+func (f *OCIFileImage) Verify() (*VerifyReport, error) {
+	ri, err := f.RootIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	rm, err := ri.RawManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := ri.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	v := verifier{
+		f:       f,
+		report:  &VerifyReport{},
+		intact:  make(map[v1.Hash]bool),
+		visited: make(map[v1.Hash]bool),
+	}
+
+	if err := v.verifyIndex(h, rm); err != nil {
+		return nil, err
+	}
+
+	if err := v.verifyObjects(); err != nil {
+		return nil, err
+	}
+
+	return v.report, nil
+}
+
+// verifyIndex verifies the blobs referenced by the index manifest b, with digest h, recursively.
+func (v *verifier) verifyIndex(h v1.Hash, b []byte) error {
+	if v.visited[h] {
+		return nil
+	}
+	v.visited[h] = true
+
+	im, err := v1.ParseIndexManifest(bytes.NewReader(b))
+	if err != nil {
+		v.report.add(h, fmt.Errorf("%w: %v", ErrBlobMalformed, err))
+		return nil
+	}
+
+	for _, desc := range im.Manifests {
+		b, ok, err := v.verifyBlob(desc)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		switch {
+		case desc.MediaType.IsIndex():
+			if err := v.verifyIndex(desc.Digest, b); err != nil {
+				return err
+			}
+
+		case desc.MediaType.IsImage():
+			if err := v.verifyImage(desc.Digest, b); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyImage verifies the config and layers referenced by the image manifest b, with digest h.
+func (v *verifier) verifyImage(h v1.Hash, b []byte) error {
+	if v.visited[h] {
+		return nil
+	}
+	v.visited[h] = true
+
+	m, err := v1.ParseManifest(bytes.NewReader(b))
+	if err != nil {
+		v.report.add(h, fmt.Errorf("%w: %v", ErrBlobMalformed, err))
+		return nil
+	}
+
+	cb, configOK, err := v.verifyBlob(m.Config)
+	if err != nil {
+		return err
+	}
+
+	layersOK := true
+	for _, ld := range m.Layers {
+		_, ok, err := v.verifyBlob(ld)
+		if err != nil {
+			return err
+		}
+		layersOK = layersOK && ok
+	}
+
+	// The diff_ids can only be checked if the config is an image config, and it and all layers
+	// are intact.
+	//nolint:exhaustive
+	switch m.Config.MediaType {
+	case types.OCIConfigJSON, types.DockerConfigJSON:
+	default:
+		return nil
+	}
+	if !configOK || !layersOK {
+		return nil
+	}
+
+	cfg, err := v1.ParseConfigFile(bytes.NewReader(cb))
+	if err != nil {
+		v.report.add(m.Config.Digest, fmt.Errorf("%w: %v", ErrBlobMalformed, err))
+		return nil
+	}
+
+	if got, want := len(cfg.RootFS.DiffIDs), len(m.Layers); got != want {
+		v.report.add(m.Config.Digest,
+			fmt.Errorf("%w: config has %v diff_ids, manifest has %v layers", ErrDiffIDMismatch, got, want))
+		return nil
+	}
+
+	for i, ld := range m.Layers {
+		h, err := v.diffID(ld)
+		if err != nil {
+			v.report.add(ld.Digest, fmt.Errorf("%w: %v", ErrBlobMalformed, err))
+			continue
+		}
+		if want := cfg.RootFS.DiffIDs[i]; h != want {
+			v.report.add(ld.Digest, fmt.Errorf("%w: got %v, want %v", ErrDiffIDMismatch, h, want))
+		}
+	}
+
+	return nil
+}
+
+// verifiedLayer is a Layer that reads its content from a specific object, so that duplicated blobs
+// do not prevent the layer from being read.
+type verifiedLayer struct {
+	*Layer
+	d sif.Descriptor
+}
+
+// Compressed returns an io.ReadCloser for the compressed layer contents.
+func (l *verifiedLayer) Compressed() (io.ReadCloser, error) {
+	return io.NopCloser(l.d.GetReader()), nil
+}
+
+// diffID returns the digest of the uncompressed content of the layer described by desc.
+func (v *verifier) diffID(desc v1.Descriptor) (v1.Hash, error) {
+	ds, err := v.f.sif.GetDescriptors(
+		sif.WithDataType(sif.DataOCIBlob),
+		sif.WithOCIBlobDigest(desc.Digest),
+	)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	if len(ds) == 0 {
+		return v1.Hash{}, sif.ErrObjectNotFound
+	}
+
+	l, err := partial.CompressedToLayer(&verifiedLayer{Layer: &Layer{f: v.f, desc: desc}, d: ds[0]})
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	rc, err := l.Uncompressed()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	defer rc.Close()
+
+	h, _, err := v1.SHA256(rc)
+	return h, err
+}
+
+// verifyBlob checks that the blob described by desc is present in the SIF, and that its content
+// matches the digest and size of desc. The content of the blob is returned if it is a manifest or
+// config, along with a boolean indicating whether the blob is intact. Any problem found is added
+// to the report.
+func (v *verifier) verifyBlob(desc v1.Descriptor) ([]byte, bool, error) {
+	// Duplicates are reported by verifyObjects, so only the first object holding the blob is
+	// checked.
+	ds, err := v.f.sif.GetDescriptors(
+		sif.WithDataType(sif.DataOCIBlob),
+		sif.WithOCIBlobDigest(desc.Digest),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(ds) == 0 {
+		if _, ok := v.intact[desc.Digest]; !ok {
+			v.report.add(desc.Digest, ErrBlobMissing)
+		}
+		v.intact[desc.Digest] = false
+		return nil, false, nil
+	}
+	d := ds[0]
+
+	var b []byte
+	if desc.MediaType.IsIndex() || desc.MediaType.IsImage() || desc.MediaType.IsConfig() {
+		if b, err = d.GetData(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if ok, seen := v.intact[desc.Digest]; seen {
+		return b, ok, nil
+	}
+
+	h, n, err := v1.SHA256(d.GetReader())
+	if err != nil {
+		return nil, false, err
+	}
+
+	ok := true
+	if h != desc.Digest {
+		v.report.add(desc.Digest, fmt.Errorf("%w: got %v", ErrBlobDigestMismatch, h))
+		ok = false
+	}
+	if n != desc.Size {
+		v.report.add(desc.Digest, fmt.Errorf("%w: got %v, want %v", ErrBlobSizeMismatch, n, desc.Size))
+		ok = false
+	}
+
+	v.intact[desc.Digest] = ok
+
+	return b, ok, nil
+}
+
+// verifyObjects reports blobs that are present in the SIF but were not referenced, and blobs that
+// are held by more than one object.
+func (v *verifier) verifyObjects() error {
+	ds, err := v.f.sif.GetDescriptors(sif.WithDataType(sif.DataOCIBlob))
+	if err != nil {
+		return err
+	}
+
+	count := make(map[v1.Hash]int)
+	for _, d := range ds {
+		h, err := d.OCIBlobDigest()
+		if err != nil {
+			return err
+		}
+
+		count[h]++
+		if count[h] == 2 {
+			v.report.add(h, ErrBlobDuplicated)
+		}
+		if _, seen := v.intact[h]; count[h] == 1 && !seen {
+			v.report.add(h, ErrBlobOrphaned)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,318 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sif_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ggcrempty "github.com/google/go-containerregistry/pkg/v1/empty"
+	ggcrmutate "github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/sylabs/oci-tools/pkg/sif"
+	ssif "github.com/sylabs/sif/v2/pkg/sif"
+)
+
+// badDiffIDImage returns a random image with a config that holds incorrect diff_ids.
+func badDiffIDImage(t *testing.T) v1.Image {
+	t.Helper()
+
+	img, err := random.Image(64, 1, random.WithSource(rand.NewSource(randomSeed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg = cfg.DeepCopy()
+	cfg.RootFS.DiffIDs[0] = v1.Hash{
+		Algorithm: "sha256",
+		Hex:       "0000000000000000000000000000000000000000000000000000000000000000",
+	}
+
+	img, err = ggcrmutate.ConfigFile(img, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// corruptBlob overwrites part of the content of the blob with digest h, in the SIF at path.
+func corruptBlob(t *testing.T, path string, ofi *sif.OCIFileImage, h v1.Hash) {
+	t.Helper()
+
+	off, err := ofi.Offset(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, off+16); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// firstLayer returns the digest of the first layer of the single image in ofi.
+func firstLayer(t *testing.T, ofi *sif.OCIFileImage) v1.Hash {
+	t.Helper()
+
+	img, err := ofi.Image(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := ls[0].Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// addBlob adds b to fi as a blob, and returns a descriptor of media type mt that refers to it.
+func addBlob(t *testing.T, fi *ssif.FileImage, mt types.MediaType, b []byte) v1.Descriptor {
+	t.Helper()
+
+	h, n, err := v1.SHA256(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addObject(t, fi, ssif.DataOCIBlob, b)
+
+	return v1.Descriptor{MediaType: mt, Size: n, Digest: h}
+}
+
+// addManifest adds an image manifest referring to config and layers to fi as a blob, and returns
+// a descriptor that refers to it.
+func addManifest(t *testing.T, fi *ssif.FileImage, config v1.Descriptor, layers ...v1.Descriptor) v1.Descriptor {
+	t.Helper()
+
+	b, err := json.Marshal(v1.Manifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIManifestSchema1,
+		Config:        config,
+		Layers:        layers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addBlob(t, fi, types.OCIManifestSchema1, b)
+}
+
+// appendRootIndex replaces the RootIndex of fi with one that also refers to desc.
+func appendRootIndex(t *testing.T, fi *ssif.FileImage, ofi *sif.OCIFileImage, desc v1.Descriptor) {
+	t.Helper()
+
+	ri, err := ofi.RootIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	im, err := ri.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	im = im.DeepCopy()
+	im.Manifests = append(im.Manifests, desc)
+
+	b, err := json.Marshal(im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fi.DeleteObjects(ssif.WithDataType(ssif.DataOCIRootIndex)); err != nil {
+		t.Fatal(err)
+	}
+	addObject(t, fi, ssif.DataOCIRootIndex, b)
+}
+
+func Test_OCIFileImage_Verify(t *testing.T) {
+	tests := []struct {
+		name      string
+		sifPath   func(*testing.T) string
+		corrupt   func(*testing.T, string, *ssif.FileImage, *sif.OCIFileImage)
+		wantErrs  []error
+		wantError bool
+	}{
+		{
+			name: "OK",
+		},
+		{
+			name: "MissingBlob",
+			corrupt: func(t *testing.T, _ string, fi *ssif.FileImage, ofi *sif.OCIFileImage) {
+				if err := fi.DeleteObjects(ssif.WithOCIBlobDigest(firstLayer(t, ofi))); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErrs: []error{sif.ErrBlobMissing},
+		},
+		{
+			name: "CorruptBlob",
+			corrupt: func(t *testing.T, path string, _ *ssif.FileImage, ofi *sif.OCIFileImage) {
+				corruptBlob(t, path, ofi, firstLayer(t, ofi))
+			},
+			wantErrs: []error{sif.ErrBlobDigestMismatch},
+		},
+		{
+			name: "OrphanedBlob",
+			corrupt: func(t *testing.T, _ string, fi *ssif.FileImage, _ *sif.OCIFileImage) {
+				addObject(t, fi, ssif.DataOCIBlob, []byte("orphan"))
+			},
+			wantErrs: []error{sif.ErrBlobOrphaned},
+		},
+		{
+			name: "DuplicatedBlob",
+			corrupt: func(t *testing.T, _ string, fi *ssif.FileImage, ofi *sif.OCIFileImage) {
+				b, err := ofi.Bytes(firstLayer(t, ofi))
+				if err != nil {
+					t.Fatal(err)
+				}
+				addObject(t, fi, ssif.DataOCIBlob, b)
+			},
+			wantErrs: []error{sif.ErrBlobDuplicated},
+		},
+		{
+			name: "DiffIDMismatch",
+			sifPath: func(t *testing.T) string {
+				path := filepath.Join(t.TempDir(), "image.sif")
+				ii := ggcrmutate.AppendManifests(ggcrempty.Index, ggcrmutate.IndexAddendum{Add: badDiffIDImage(t)})
+				if err := sif.Write(path, ii); err != nil {
+					t.Fatal(err)
+				}
+				return path
+			},
+			wantErrs: []error{sif.ErrDiffIDMismatch},
+		},
+		{
+			name: "DiffIDMismatchReferencedTwice",
+			sifPath: func(t *testing.T) string {
+				path := filepath.Join(t.TempDir(), "image.sif")
+				img := badDiffIDImage(t)
+				ii := ggcrmutate.AppendManifests(ggcrempty.Index,
+					ggcrmutate.IndexAddendum{Add: img},
+					ggcrmutate.IndexAddendum{
+						Add: img,
+						Descriptor: v1.Descriptor{
+							Annotations: map[string]string{"org.opencontainers.image.ref.name": "second"},
+						},
+					},
+				)
+				if err := sif.Write(path, ii); err != nil {
+					t.Fatal(err)
+				}
+				return path
+			},
+			wantErrs: []error{sif.ErrDiffIDMismatch},
+		},
+		{
+			name: "MalformedIndex",
+			corrupt: func(t *testing.T, _ string, fi *ssif.FileImage, ofi *sif.OCIFileImage) {
+				appendRootIndex(t, fi, ofi, addBlob(t, fi, types.OCIImageIndex, []byte("malformed")))
+			},
+			wantErrs: []error{sif.ErrBlobMalformed},
+		},
+		{
+			name: "MalformedManifest",
+			corrupt: func(t *testing.T, _ string, fi *ssif.FileImage, ofi *sif.OCIFileImage) {
+				appendRootIndex(t, fi, ofi, addBlob(t, fi, types.OCIManifestSchema1, []byte("malformed")))
+			},
+			wantErrs: []error{sif.ErrBlobMalformed},
+		},
+		{
+			name: "MalformedConfig",
+			corrupt: func(t *testing.T, _ string, fi *ssif.FileImage, ofi *sif.OCIFileImage) {
+				config := addBlob(t, fi, types.OCIConfigJSON, []byte("malformed"))
+				appendRootIndex(t, fi, ofi, addManifest(t, fi, config))
+			},
+			wantErrs: []error{sif.ErrBlobMalformed},
+		},
+		{
+			name: "MalformedLayer",
+			corrupt: func(t *testing.T, _ string, fi *ssif.FileImage, ofi *sif.OCIFileImage) {
+				// A gzip header, followed by content that cannot be decompressed.
+				layer := addBlob(t, fi, types.OCILayer, []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xffmalformed"))
+
+				b, err := json.Marshal(v1.ConfigFile{
+					RootFS: v1.RootFS{Type: "layers", DiffIDs: []v1.Hash{layer.Digest}},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				config := addBlob(t, fi, types.OCIConfigJSON, b)
+
+				appendRootIndex(t, fi, ofi, addManifest(t, fi, config, layer))
+			},
+			wantErrs: []error{sif.ErrBlobMalformed},
+		},
+		{
+			name: "NoRootIndex",
+			corrupt: func(t *testing.T, _ string, fi *ssif.FileImage, _ *sif.OCIFileImage) {
+				if err := fi.DeleteObjects(ssif.WithDataType(ssif.DataOCIRootIndex)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			if tt.sifPath != nil {
+				path = tt.sifPath(t)
+			} else {
+				path = corpus.SIF(t, "hello-world-docker-v2-manifest", sif.OptWriteWithSpareDescriptorCapacity(4))
+			}
+
+			fi, err := ssif.LoadContainerFromPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			ofi, err := sif.FromFileImage(fi)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.corrupt != nil {
+				tt.corrupt(t, path, fi, ofi)
+			}
+
+			r, err := ofi.Verify()
+			if (err != nil) != tt.wantError {
+				t.Fatalf("Verify() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+
+			if got, want := len(r.Problems), len(tt.wantErrs); got != want {
+				t.Fatalf("got %v problems, want %v: %v", got, want, r.Err())
+			}
+			for i, p := range r.Problems {
+				if !errors.Is(p, tt.wantErrs[i]) {
+					t.Errorf("got problem %v, want %v", p, tt.wantErrs[i])
+				}
+			}
+			if got, want := r.OK(), len(tt.wantErrs) == 0; got != want {
+				t.Errorf("got OK %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-// Copyright 2023 Sylabs Inc. All rights reserved.
+// Copyright 2023-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -8,10 +8,13 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"os"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/sylabs/sif/v2/pkg/sif"
+	"golang.org/x/sync/errgroup"
 )
 
 // WriteBlob writes a blob to the SIF f, as a DataOCIBlob descriptor.
@@ -25,8 +28,12 @@ func (f *OCIFileImage) writeRootIndex(r io.Reader) error {
 	return f.writeBlob(r, sif.DataOCIRootIndex)
 }
 
+// blobAlignment is the alignment, in bytes, of the blobs written to a SIF. A value of zero or one
+// indicates that blobs are not aligned, so no padding is inserted between them.
+const blobAlignment = 0
+
 func (f *OCIFileImage) writeBlob(r io.Reader, t sif.DataType) error {
-	di, err := sif.NewDescriptorInput(t, r)
+	di, err := sif.NewDescriptorInput(t, r, sif.OptObjectAlignment(blobAlignment))
 	if err != nil {
 		return err
 	}
@@ -34,32 +41,47 @@ func (f *OCIFileImage) writeBlob(r io.Reader, t sif.DataType) error {
 	return f.sif.AddObject(di)
 }
 
-// writeImage writes an image and all of its manifests and blobs to f. This
-// function does not update the RootIndex.
-func (f *OCIFileImage) writeImage(img v1.Image) error {
+// hasBlob returns true if f holds a blob with digest h.
+func (f *OCIFileImage) hasBlob(h v1.Hash) bool {
+	_, err := f.sif.GetDescriptor(sif.WithOCIBlobDigest(h))
+	return err == nil
+}
+
+// writeImage writes an image and all of its manifests and blobs to f, skipping
+// any blobs that are already present. This function does not update the
+// RootIndex.
+func (f *OCIFileImage) writeImage(img v1.Image, wo *writeOpts) error {
 	ls, err := img.Layers()
 	if err != nil {
 		return err
 	}
 
-	for _, l := range ls {
-		rc, err := l.Compressed()
+	if err := f.writeLayers(ls, wo); err != nil {
+		return err
+	}
+
+	m, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+
+	if !f.hasBlob(m.Config.Digest) {
+		cfg, err := img.RawConfigFile()
 		if err != nil {
 			return err
 		}
 
-		if err := f.WriteBlob(rc); err != nil {
+		if err := f.WriteBlob(bytes.NewReader(cfg)); err != nil {
 			return err
 		}
 	}
 
-	cfg, err := img.RawConfigFile()
+	h, err := img.Digest()
 	if err != nil {
 		return err
 	}
-
-	if err := f.WriteBlob(bytes.NewReader(cfg)); err != nil {
-		return err
+	if f.hasBlob(h) {
+		return nil
 	}
 
 	rm, err := img.RawManifest()
@@ -70,6 +92,108 @@ func (f *OCIFileImage) writeImage(img v1.Image) error {
 	return f.WriteBlob(bytes.NewReader(rm))
 }
 
+// writeLayers writes the compressed content of each of ls to f, in order,
+// skipping any blobs that are already present. If wo specifies a concurrency
+// greater than one, layers are first fetched concurrently into temporary files.
+func (f *OCIFileImage) writeLayers(ls []v1.Layer, wo *writeOpts) error {
+	var missing []v1.Layer
+
+	seen := make(map[v1.Hash]bool)
+	for _, l := range ls {
+		h, err := l.Digest()
+		if err != nil {
+			return err
+		}
+		if seen[h] || f.hasBlob(h) {
+			continue
+		}
+		seen[h] = true
+
+		missing = append(missing, l)
+	}
+
+	if wo.concurrency <= 1 || len(missing) <= 1 {
+		for _, l := range missing {
+			rc, err := l.Compressed()
+			if err != nil {
+				return err
+			}
+
+			if err := f.WriteBlob(rc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	paths, err := fetchLayers(missing, wo.concurrency, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := f.writeFileBlob(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileBlob writes the content of the file at path to f as a blob, and
+// removes the file.
+func (f *OCIFileImage) writeFileBlob(path string) error {
+	r, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := f.WriteBlob(r); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// fetchLayers writes the compressed content of each of ls to a temporary file
+// in dir, using up to n concurrent readers. The paths of the files are
+// returned, in the order of ls.
+func fetchLayers(ls []v1.Layer, n int, dir string) ([]string, error) {
+	paths := make([]string, len(ls))
+
+	var g errgroup.Group
+	g.SetLimit(n)
+
+	for i, l := range ls {
+		g.Go(func() error {
+			rc, err := l.Compressed()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+
+			f, err := os.CreateTemp(dir, "")
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			paths[i] = f.Name()
+
+			_, err = io.Copy(f, rc)
+			return err
+		})
+	}
+
+	return paths, g.Wait()
+}
+
 type withBlob interface {
 	Blob(v1.Hash) (io.ReadCloser, error)
 }
@@ -101,8 +225,9 @@ func blobFromIndex(ii v1.ImageIndex, digest v1.Hash) (io.ReadCloser, error) {
 }
 
 // writeIndex writes an index and all of its child indexes, manifests and blobs
-// to f.
-func (f *OCIFileImage) writeIndex(ii v1.ImageIndex, rootIndex bool) error {
+// to f, skipping any blobs that are already present. Descriptors with a media
+// type other than an image or index are written as opaque blobs.
+func (f *OCIFileImage) writeIndex(ii v1.ImageIndex, rootIndex bool, wo *writeOpts) error {
 	index, err := ii.IndexManifest()
 	if err != nil {
 		return err
@@ -117,7 +242,7 @@ func (f *OCIFileImage) writeIndex(ii v1.ImageIndex, rootIndex bool) error {
 				return err
 			}
 
-			if err := f.writeIndex(ii, false); err != nil {
+			if err := f.writeIndex(ii, false, wo); err != nil {
 				return err
 			}
 
@@ -127,11 +252,15 @@ func (f *OCIFileImage) writeIndex(ii v1.ImageIndex, rootIndex bool) error {
 				return err
 			}
 
-			if err := f.writeImage(img); err != nil {
+			if err := f.writeImage(img, wo); err != nil {
 				return err
 			}
 
 		default:
+			if f.hasBlob(desc.Digest) {
+				continue
+			}
+
 			rc, err := blobFromIndex(ii, desc.Digest)
 			if err != nil {
 				return err
@@ -153,21 +282,50 @@ func (f *OCIFileImage) writeIndex(ii v1.ImageIndex, rootIndex bool) error {
 		return f.writeRootIndex(bytes.NewReader(m))
 	}
 
+	h, err := ii.Digest()
+	if err != nil {
+		return err
+	}
+	if f.hasBlob(h) {
+		return nil
+	}
+
 	return f.WriteBlob(bytes.NewReader(m))
 }
 
-// numDescriptorsForImage returns the number of descriptors required to store img.
-func numDescriptorsForImage(img v1.Image) (int64, error) {
-	ls, err := img.Layers()
+// numDescriptorsForImage returns the number of descriptors required to store img, excluding blobs
+// with digests in seen. The digests of blobs that are counted are added to seen, mapped to their
+// sizes.
+func numDescriptorsForImage(img v1.Image, seen map[v1.Hash]int64) (int64, error) {
+	m, err := img.Manifest()
 	if err != nil {
 		return 0, err
 	}
 
-	return int64(len(ls) + 2), nil
+	d, err := partial.Descriptor(img)
+	if err != nil {
+		return 0, err
+	}
+
+	ds := []v1.Descriptor{*d, m.Config}
+	ds = append(ds, m.Layers...)
+
+	var count int64
+
+	for _, d := range ds {
+		if _, ok := seen[d.Digest]; !ok {
+			seen[d.Digest] = d.Size
+			count++
+		}
+	}
+
+	return count, nil
 }
 
-// numDescriptorsForIndex returns the number of descriptors required to store ii.
-func numDescriptorsForIndex(ii v1.ImageIndex) (int64, error) {
+// numDescriptorsForIndex returns the number of descriptors required to store ii, excluding blobs
+// with digests in seen. The digests of blobs that are counted are added to seen, mapped to their
+// sizes.
+func numDescriptorsForIndex(ii v1.ImageIndex, seen map[v1.Hash]int64) (int64, error) {
 	index, err := ii.IndexManifest()
 	if err != nil {
 		return 0, err
@@ -184,20 +342,25 @@ func numDescriptorsForIndex(ii v1.ImageIndex) (int64, error) {
 				return 0, err
 			}
 
-			n, err := numDescriptorsForIndex(ii)
+			n, err := numDescriptorsForIndex(ii, seen)
 			if err != nil {
 				return 0, err
 			}
 
 			count += n
 
+			if _, ok := seen[desc.Digest]; !ok {
+				seen[desc.Digest] = desc.Size
+				count++
+			}
+
 		case types.DockerManifestSchema2, types.OCIManifestSchema1:
 			img, err := ii.Image(desc.Digest)
 			if err != nil {
 				return 0, err
 			}
 
-			n, err := numDescriptorsForImage(img)
+			n, err := numDescriptorsForImage(img, seen)
 			if err != nil {
 				return 0, err
 			}
@@ -205,16 +368,20 @@ func numDescriptorsForIndex(ii v1.ImageIndex) (int64, error) {
 			count += n
 
 		default:
-			count++
+			if _, ok := seen[desc.Digest]; !ok {
+				seen[desc.Digest] = desc.Size
+				count++
+			}
 		}
 	}
 
-	return count + 1, nil
+	return count, nil
 }
 
 // writeOpts accumulates write options.
 type writeOpts struct {
 	spareDescriptors int64
+	concurrency      int
 }
 
 // WriteOpt are used to specify write options.
@@ -229,8 +396,23 @@ func OptWriteWithSpareDescriptorCapacity(n int64) WriteOpt {
 	}
 }
 
+// OptWriteWithConcurrency specifies that up to n layers may be fetched concurrently. Layers are
+// fetched into temporary files, in the directory returned by os.TempDir, and are then written to
+// the SIF in the same order as if fetched sequentially, so that the SIF is reproducible. By
+// default, layers are fetched sequentially, and written directly to the SIF.
+func OptWriteWithConcurrency(n int) WriteOpt {
+	return func(wo *writeOpts) error {
+		wo.concurrency = n
+		return nil
+	}
+}
+
 // Write constructs a SIF at path from an ImageIndex, which becomes the
-// RootIndex in the SIF.
+// RootIndex in the SIF. Descriptors in ii with a media type other than an image
+// or index, such as an artifact that is not wrapped in an image manifest, are
+// stored as opaque blobs. Their content is read using a Blob or Layer method of
+// ii, as implemented by the indexes returned by this package and by the layout,
+// mutate and remote packages.
 //
 // By default, the SIF is created with the exact number of descriptors required
 // to represent ii. To include spare descriptor capacity, consider using
@@ -246,10 +428,12 @@ func Write(path string, ii v1.ImageIndex, opts ...WriteOpt) error {
 		}
 	}
 
-	n, err := numDescriptorsForIndex(ii)
+	// One descriptor is required for the RootIndex itself.
+	n, err := numDescriptorsForIndex(ii, make(map[v1.Hash]int64))
 	if err != nil {
 		return err
 	}
+	n++
 
 	fi, err := sif.CreateContainerAtPath(path,
 		sif.OptCreateDeterministic(),
@@ -260,7 +444,7 @@ func Write(path string, ii v1.ImageIndex, opts ...WriteOpt) error {
 	}
 	defer func() { _ = fi.UnloadContainer() }()
 
-	f := OCIFileImage{fi}
+	f := OCIFileImage{sif: fi}
 
-	return f.writeIndex(ii, true)
+	return f.writeIndex(ii, true, &wo)
 }
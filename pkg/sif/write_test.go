@@ -5,6 +5,7 @@
 package sif_test
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
@@ -73,3 +74,31 @@ func TestWrite(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteConcurrency(t *testing.T) {
+	ii := ggcrmutate.AppendManifests(
+		ggcrempty.Index,
+		ggcrmutate.IndexAddendum{Add: corpus.Image(t, "many-layers")})
+
+	want := filepath.Join(t.TempDir(), "sequential.sif")
+	if err := sif.Write(want, ii); err != nil {
+		t.Fatal(err)
+	}
+
+	got := filepath.Join(t.TempDir(), "concurrent.sif")
+	if err := sif.Write(got, ii, sif.OptWriteWithConcurrency(4)); err != nil {
+		t.Fatal(err)
+	}
+
+	wantBytes, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotBytes, err := os.ReadFile(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotBytes, wantBytes) {
+		t.Error("concurrent write differs from sequential write")
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// copyOpts holds options that apply to a Copy operation.
+type copyOpts struct {
+	getOpts         []GetOpt
+	writeOpts       []WriteOpt
+	cosign          bool
+	cosignRecursive bool
+}
+
+// CopyOpt sets an option that applies to a Copy operation.
+type CopyOpt func(*copyOpts) error
+
+// CopyWithGetOpts sets the options used to select the image or index to copy
+// from the source.
+func CopyWithGetOpts(opts ...GetOpt) CopyOpt {
+	return func(o *copyOpts) error {
+		o.getOpts = opts
+		return nil
+	}
+}
+
+// CopyWithWriteOpts sets the options used when writing the image or index to
+// the sink.
+func CopyWithWriteOpts(opts ...WriteOpt) CopyOpt {
+	return func(o *copyOpts) error {
+		o.writeOpts = opts
+		return nil
+	}
+}
+
+// CopyWithCosign sets whether cosign signature, attestation and attachment
+// images associated with the image or index are copied. By default, they are
+// copied.
+func CopyWithCosign(b bool) CopyOpt {
+	return func(o *copyOpts) error {
+		o.cosign = b
+		return nil
+	}
+}
+
+// CopyWithCosignRecursive sets whether, when copying an index, cosign images
+// associated with each of its manifests are also copied. By default, they are
+// copied.
+func CopyWithCosignRecursive(b bool) CopyOpt {
+	return func(o *copyOpts) error {
+		o.cosignRecursive = b
+		return nil
+	}
+}
+
+// CopiedManifest describes an image or index written to a sink by Copy.
+type CopiedManifest struct {
+	// Reference is the reference with which the manifest was written, or nil.
+	Reference name.Reference
+	// Digest is the digest of the manifest.
+	Digest v1.Hash
+	// MediaType is the media type of the manifest.
+	MediaType types.MediaType
+}
+
+// CopyReport describes the result of a Copy operation.
+type CopyReport struct {
+	// Manifest describes the image or index that was copied.
+	Manifest CopiedManifest
+	// Cosign describes the cosign signature, attestation and attachment images
+	// that were copied.
+	Cosign []CopiedManifest
+	// Descriptors is the number of descriptors required to hold the copied
+	// images and indexes in a new SIF file.
+	Descriptors int64
+}
+
+// copyPlan holds the images and indexes to be written to a sink by Copy.
+type copyPlan struct {
+	w            Writable
+	cosignImages []ReferencedImage
+	report       CopyReport
+}
+
+// planCopy selects the image or index from src that should be copied, along
+// with any associated cosign images.
+func planCopy(ctx context.Context, src Source, co copyOpts) (*copyPlan, error) {
+	d, err := src.Get(ctx, co.getOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	wOpts := writeOpts{}
+	for _, opt := range co.writeOpts {
+		if err := opt(&wOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	p := copyPlan{}
+
+	// One descriptor is required for the root index of a SIF.
+	p.report.Descriptors = 1
+
+	var h v1.Hash
+
+	switch mt := d.MediaType(); {
+	case mt.IsImage():
+		img, err := d.Image()
+		if err != nil {
+			return nil, err
+		}
+		if h, err = img.Digest(); err != nil {
+			return nil, err
+		}
+		n, err := NumDescriptorsForImage(img)
+		if err != nil {
+			return nil, err
+		}
+		p.w = img
+		p.report.Descriptors += n
+	case mt.IsIndex():
+		ii, err := d.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+		if h, err = ii.Digest(); err != nil {
+			return nil, err
+		}
+		n, err := NumDescriptorsForIndex(ii)
+		if err != nil {
+			return nil, err
+		}
+		p.w = ii
+		p.report.Descriptors += n
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedMediaType, mt)
+	}
+
+	p.report.Manifest = CopiedManifest{
+		Reference: wOpts.reference,
+		Digest:    h,
+		MediaType: d.MediaType(),
+	}
+
+	if sd, ok := d.(SignedDescriptor); ok && co.cosign {
+		p.cosignImages, err = sd.CosignImages(ctx, co.cosignRecursive)
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := NumDescriptorsForCosign(p.cosignImages)
+		if err != nil {
+			return nil, err
+		}
+		p.report.Descriptors += n
+
+		for _, ri := range p.cosignImages {
+			h, err := ri.Img.Digest()
+			if err != nil {
+				return nil, err
+			}
+			mt, err := ri.Img.MediaType()
+			if err != nil {
+				return nil, err
+			}
+			p.report.Cosign = append(p.report.Cosign, CopiedManifest{
+				Reference: ri.Ref,
+				Digest:    h,
+				MediaType: mt,
+			})
+		}
+	}
+
+	return &p, nil
+}
+
+// execute writes the images and indexes in the plan to dst.
+func (p *copyPlan) execute(ctx context.Context, dst Sink, co copyOpts) error {
+	if err := dst.Write(ctx, p.w, co.writeOpts...); err != nil {
+		return err
+	}
+
+	for _, ri := range p.cosignImages {
+		if err := dst.Write(ctx, ri.Img, WriteWithReference(ri.Ref)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func handleCopyOpts(opts ...CopyOpt) (copyOpts, error) {
+	co := copyOpts{
+		cosign:          true,
+		cosignRecursive: true,
+	}
+	for _, opt := range opts {
+		if err := opt(&co); err != nil {
+			return copyOpts{}, err
+		}
+	}
+	return co, nil
+}
+
+// Copy copies an image or index from src to dst. By default, the image or
+// index is selected from src as if by calling Get without options. Use
+// CopyWithGetOpts to select a specific image or index.
+//
+// If the image or index selected from src is a SignedDescriptor, associated
+// cosign signature, attestation and attachment images are also copied, and
+// written to dst with their '_cosign' placeholder references. This can be disabled with
+// CopyWithCosign.
+//
+// When dst is an existing SIF file, its descriptor capacity is grown
+// automatically if it is insufficient to hold the copied images and indexes.
+// To copy to a new SIF file, use CopyToSIF.
+func Copy(ctx context.Context, src Source, dst Sink, opts ...CopyOpt) (*CopyReport, error) {
+	co, err := handleCopyOpts(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := planCopy(ctx, src, co)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.execute(ctx, dst, co); err != nil {
+		return nil, err
+	}
+
+	return &p.report, nil
+}
+
+// CopyToSIF copies an image or index from src to a new SIF file at dst, as
+// with Copy. The SIF file is created up front with the descriptor capacity
+// reported in CopyReport.Descriptors, rather than being grown as the copied
+// images and indexes are written.
+func CopyToSIF(ctx context.Context, src Source, dst string, opts ...CopyOpt) (*CopyReport, error) {
+	co, err := handleCopyOpts(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := planCopy(ctx, src, co)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := SIFEmpty(dst, p.report.Descriptors)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.execute(ctx, s, co); err != nil {
+		return nil, err
+	}
+
+	return &p.report, nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// checkCopied verifies that the image or index, and cosign images, described
+// by r can be retrieved from s.
+func checkCopied(t *testing.T, s Source, r *CopyReport) {
+	t.Helper()
+
+	opts := []GetOpt{GetWithDigest(r.Manifest.Digest)}
+	if r.Manifest.Reference != nil {
+		opts = append(opts, GetWithReference(r.Manifest.Reference))
+	}
+	d, err := s.Get(t.Context(), opts...)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got, want := d.MediaType(), r.Manifest.MediaType; got != want {
+		t.Errorf("got media type %v, want %v", got, want)
+	}
+
+	for _, c := range r.Cosign {
+		if _, err := s.Get(t.Context(), GetWithDigest(c.Digest), GetWithReference(c.Reference)); err != nil {
+			t.Errorf("Get(%v) error = %v", c.Reference, err)
+		}
+	}
+}
+
+func TestCopy(t *testing.T) {
+	imgDigest := v1.Hash{Algorithm: "sha256", Hex: "432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338"}
+	idxDigest := v1.Hash{Algorithm: "sha256", Hex: "00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed"}
+	ref := name.MustParseReference("my:image", name.WithDefaultRegistry(""))
+
+	tests := []struct {
+		name            string
+		src             string
+		opts            []CopyOpt
+		wantDigest      v1.Hash
+		wantMediaType   types.MediaType
+		wantCosign      int
+		wantDescriptors int64
+	}{
+		{
+			name:            "Image",
+			src:             "hello-world-docker-v2-manifest",
+			wantDigest:      imgDigest,
+			wantMediaType:   types.DockerManifestSchema2,
+			wantCosign:      0,
+			wantDescriptors: 4,
+		},
+		{
+			name:            "ImageCosign",
+			src:             "hello-world-cosign-manifest",
+			wantDigest:      imgDigest,
+			wantMediaType:   types.DockerManifestSchema2,
+			wantCosign:      2,
+			wantDescriptors: 10,
+		},
+		{
+			name:            "ImageCosignDisabled",
+			src:             "hello-world-cosign-manifest",
+			opts:            []CopyOpt{CopyWithCosign(false)},
+			wantDigest:      imgDigest,
+			wantMediaType:   types.DockerManifestSchema2,
+			wantCosign:      0,
+			wantDescriptors: 4,
+		},
+		{
+			name:            "ImageWithReference",
+			src:             "hello-world-cosign-manifest",
+			opts:            []CopyOpt{CopyWithWriteOpts(WriteWithReference(ref))},
+			wantDigest:      imgDigest,
+			wantMediaType:   types.DockerManifestSchema2,
+			wantCosign:      2,
+			wantDescriptors: 10,
+		},
+		{
+			name:            "IndexCosign",
+			src:             "hello-world-cosign-manifest-list",
+			wantDigest:      idxDigest,
+			wantMediaType:   types.DockerManifestList,
+			wantCosign:      11,
+			wantDescriptors: 62,
+		},
+		{
+			name:            "IndexCosignNotRecursive",
+			src:             "hello-world-cosign-manifest-list",
+			opts:            []CopyOpt{CopyWithCosignRecursive(false)},
+			wantDigest:      idxDigest,
+			wantMediaType:   types.DockerManifestList,
+			wantCosign:      2,
+			wantDescriptors: 35,
+		},
+		{
+			name: "IndexPlatform",
+			src:  "hello-world-cosign-manifest-list",
+			opts: []CopyOpt{
+				CopyWithGetOpts(GetWithPlatform(v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"})),
+			},
+			wantDigest:      imgDigest,
+			wantMediaType:   types.DockerManifestSchema2,
+			wantCosign:      1,
+			wantDescriptors: 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := SIFFromPath(corpus.SIF(t, tt.src))
+			if err != nil {
+				t.Fatalf("SIFFromPath() error = %v", err)
+			}
+
+			dst, err := OCIEmpty(filepath.Join(t.TempDir(), "layout"))
+			if err != nil {
+				t.Fatalf("OCIEmpty() error = %v", err)
+			}
+
+			r, err := Copy(t.Context(), src, dst, tt.opts...)
+			if err != nil {
+				t.Fatalf("Copy() error = %v", err)
+			}
+
+			if got, want := r.Manifest.Digest, tt.wantDigest; got != want {
+				t.Errorf("got digest %v, want %v", got, want)
+			}
+			if got, want := r.Manifest.MediaType, tt.wantMediaType; got != want {
+				t.Errorf("got media type %v, want %v", got, want)
+			}
+			if got, want := len(r.Cosign), tt.wantCosign; got != want {
+				t.Errorf("got %v cosign images, want %v", got, want)
+			}
+			if got, want := r.Descriptors, tt.wantDescriptors; got != want {
+				t.Errorf("got %v descriptors, want %v", got, want)
+			}
+
+			checkCopied(t, dst, r)
+		})
+	}
+}
+
+func TestCopyToSIF(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		opts []CopyOpt
+	}{
+		{
+			name: "Image",
+			src:  "hello-world-docker-v2-manifest",
+		},
+		{
+			name: "ImageCosign",
+			src:  "hello-world-cosign-manifest",
+		},
+		{
+			name: "IndexCosign",
+			src:  "hello-world-cosign-manifest-list",
+		},
+		{
+			name: "IndexCosignNotRecursive",
+			src:  "hello-world-cosign-manifest-list",
+			opts: []CopyOpt{CopyWithCosignRecursive(false)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := OCIFromPath(corpus.ImagePath(tt.src))
+			if err != nil {
+				t.Fatalf("OCIFromPath() error = %v", err)
+			}
+
+			path := filepath.Join(t.TempDir(), "test.sif")
+
+			r, err := CopyToSIF(t.Context(), src, path, tt.opts...)
+			if err != nil {
+				t.Fatalf("CopyToSIF() error = %v", err)
+			}
+
+			dst, err := SIFFromPath(path)
+			if err != nil {
+				t.Fatalf("SIFFromPath() error = %v", err)
+			}
+
+			checkCopied(t, dst, r)
+		})
+	}
+}
+
+func TestCopyToRegistry(t *testing.T) {
+	host := newTestRegistry(t)
+	repo, err := name.NewRepository(host + "/my/image")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := SIFFromPath(corpus.SIF(t, "hello-world-cosign-manifest"))
+	if err != nil {
+		t.Fatalf("SIFFromPath() error = %v", err)
+	}
+
+	dst, err := RegistryFromReference(repo.Tag("latest"))
+	if err != nil {
+		t.Fatalf("RegistryFromReference() error = %v", err)
+	}
+
+	r, err := Copy(t.Context(), src, dst)
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+
+	if _, err := remote.Get(repo.Digest(r.Manifest.Digest.String())); err != nil {
+		t.Errorf("remote.Get() error = %v", err)
+	}
+
+	// Cosign images must be written to the repository of the sink.
+	for _, c := range r.Cosign {
+		d, err := remote.Get(repo.Tag(c.Reference.Identifier()))
+		if err != nil {
+			t.Fatalf("remote.Get() error = %v", err)
+		}
+		if got, want := d.Digest, c.Digest; got != want {
+			t.Errorf("got digest %v, want %v", got, want)
+		}
+	}
+}
@@ -1,41 +1,68 @@
-// Copyright 2024-2025 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
 package sourcesink
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
 
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
 	cosignoci "github.com/sigstore/cosign/v2/pkg/oci"
+	cosignempty "github.com/sigstore/cosign/v2/pkg/oci/empty"
 	cosignremote "github.com/sigstore/cosign/v2/pkg/oci/remote"
+	cosignsignature "github.com/sigstore/cosign/v2/pkg/oci/signature"
 )
 
 // SignedDescriptor provides access to cosign signatures stored against it.
 type SignedDescriptor interface {
-	// CosignImages checks for image manifests providing cosign signatures &
-	// attestations that are associated with the image or index with the descriptor
-	// If image manifests providing cosign signatures and / or attestations
-	// exist, then these images are returned in a name.Reference -> v1.Image map.
+	// CosignImages checks for image manifests providing cosign signatures,
+	// attestations & attachments (such as SBOMs) that are associated with the
+	// image or index with the descriptor. If image manifests providing cosign
+	// signatures, attestations and / or attachments exist, then these images
+	// are returned in a name.Reference -> v1.Image map.
 	//
 	// If recursive is true, then if the descriptor is an index, we also check for
-	// signatures and attestations for each of its associated manifests.
+	// signatures, attestations and attachments for each of its associated
+	// manifests.
 	//
 	// In the returned map, the images are referenced as '_cosign:<tag>', where
 	// <tag> matches the tag at src. The '_cosign' repository placeholder string
 	// is used instead of any original registry & repository names.
 	CosignImages(ctx context.Context, recursive bool) ([]ReferencedImage, error)
 	// SignedImage wraps an image Descriptor as a cosign oci.SignedImage,
-	// allowing access to signatures and attestations stored alongside the image.
+	// allowing access to signatures, attestations and attachments stored alongside
+	// the image.
 	SignedImage(context.Context) (cosignoci.SignedImage, error)
 	// SignedImageIndex wraps an image index Descriptor as a cosign oci.SignedImageIndex,
-	// allowing access to signatures and attestations stored alongside the image.
+	// allowing access to signatures, attestations and attachments stored alongside
+	// the image.
 	SignedImageIndex(context.Context) (cosignoci.SignedImageIndex, error)
 }
 
+// CosignWriter is implemented by sinks that can store cosign signatures and
+// attestations alongside the images and indexes they hold.
+type CosignWriter interface {
+	// WriteSignatures stores sigs as cosign signatures for the image or index
+	// with digest h, merging them with any signatures already held for h.
+	WriteSignatures(ctx context.Context, h v1.Hash, sigs cosignoci.Signatures) error
+	// WriteAttestations stores atts as cosign attestations for the image or
+	// index with digest h, merging them with any attestations already held for
+	// h.
+	WriteAttestations(ctx context.Context, h v1.Hash, atts cosignoci.Signatures) error
+}
+
 // CosignPlaceholderRepo is a placeholder repository name for cosign images.
 const CosignPlaceholderRepo = "_cosign"
 
@@ -60,12 +87,129 @@ func NumDescriptorsForCosign(imgs []ReferencedImage) (int64, error) {
 var cosignSuffixes = []string{
 	cosignremote.SignatureTagSuffix,
 	cosignremote.AttestationTagSuffix,
+	cosignremote.SBOMTagSuffix,
+}
+
+// cosignAttachmentSuffixes returns the suffixes of named attachments found in
+// names, keyed by the digest of the image or index they are associated with.
+// names holds the values of ref.name annotations at a source. Only names in
+// the '_cosign' placeholder repository, with a suffix not in cosignSuffixes,
+// are considered.
+func cosignAttachmentSuffixes(names []string) map[v1.Hash][]string {
+	suffixes := make(map[v1.Hash][]string)
+
+	for _, n := range names {
+		ref, err := name.ParseReference(n, name.WithDefaultRegistry(""))
+		if err != nil {
+			continue
+		}
+		if ref.Context().RegistryStr() != "" || ref.Context().RepositoryStr() != CosignPlaceholderRepo {
+			continue
+		}
+		h, suffix, err := parseCosignTag(ref.Identifier())
+		if err != nil || slices.Contains(cosignSuffixes, suffix) {
+			continue
+		}
+		suffixes[h] = append(suffixes[h], suffix)
+	}
+
+	for _, s := range suffixes {
+		slices.Sort(s)
+	}
+
+	return suffixes
+}
+
+// cosignTargets returns the digests that should be checked for associated cosign
+// images, for the image or index d with digest h. If recursive is true, and d is
+// an index, the digests of the manifests it references are also returned.
+func cosignTargets(d Descriptor, h v1.Hash, recursive bool) ([]v1.Hash, error) {
+	targets := []v1.Hash{h}
+
+	if d.MediaType().IsIndex() && recursive {
+		rmf, err := d.RawManifest()
+		if err != nil {
+			return nil, err
+		}
+		mf, err := v1.ParseIndexManifest(bytes.NewBuffer(rmf))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mf.Manifests {
+			targets = append(targets, m.Digest)
+		}
+	}
+
+	return targets, nil
+}
+
+// refNames returns the values of the ref.name annotations of descs.
+func refNames(descs []v1.Descriptor) []string {
+	names := []string{}
+	for _, desc := range descs {
+		if n := desc.Annotations[imagespec.AnnotationRefName]; n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// cosignImages checks for cosign signature, attestation and attachment images
+// associated with each of the targets, using find to look up each image by
+// reference. find must return a nil image, and nil error, if no image exists
+// for a reference. In addition to signatures, attestations and SBOMs, any named
+// attachments found in names, which holds the values of ref.name annotations at
+// the source, are checked for.
+func cosignImages(targets []v1.Hash, names []string, find func(name.Reference) (v1.Image, error)) ([]ReferencedImage, error) {
+	csImgs := []ReferencedImage{}
+
+	attachments := cosignAttachmentSuffixes(names)
+
+	for _, target := range targets {
+		for _, suffix := range slices.Concat(cosignSuffixes, attachments[target]) {
+			csRef, err := CosignRef(target, nil, suffix)
+			if err != nil {
+				return nil, err
+			}
+			slog.Debug("checking for cosign image", slog.String("ref", csRef.Name()))
+			csImg, err := find(csRef)
+			if err != nil {
+				return nil, err
+			}
+			if csImg == nil {
+				continue
+			}
+			slog.Debug("found cosign image", slog.String("ref", csRef.Name()))
+			csImgs = append(csImgs, ReferencedImage{Ref: csRef, Img: csImg})
+		}
+	}
+	return csImgs, nil
 }
 
 func CosignTag(h v1.Hash, suffix string) string {
 	return fmt.Sprint(h.Algorithm, "-", h.Hex, ".", suffix)
 }
 
+var errCosignTag = errors.New("invalid cosign tag")
+
+// parseCosignTag returns the digest and suffix encoded in a cosign tag, of the
+// form returned by CosignTag.
+func parseCosignTag(tag string) (v1.Hash, string, error) {
+	digest, suffix, ok := strings.Cut(tag, ".")
+	if !ok {
+		return v1.Hash{}, "", fmt.Errorf("%w: %v", errCosignTag, tag)
+	}
+	alg, hex, ok := strings.Cut(digest, "-")
+	if !ok {
+		return v1.Hash{}, "", fmt.Errorf("%w: %v", errCosignTag, tag)
+	}
+	h, err := v1.NewHash(alg + ":" + hex)
+	if err != nil {
+		return v1.Hash{}, "", fmt.Errorf("%w: %v", errCosignTag, tag)
+	}
+	return h, suffix, nil
+}
+
 func CosignRef(imgDigest v1.Hash, imgRef name.Reference, suffix string, opts ...name.Option) (name.Reference, error) {
 	t := CosignTag(imgDigest, suffix)
 	repo := CosignPlaceholderRepo
@@ -75,3 +219,218 @@ func CosignRef(imgDigest v1.Hash, imgRef name.Reference, suffix string, opts ...
 	opts = append(opts, name.WithDefaultRegistry(""))
 	return name.ParseReference(repo+":"+t, opts...)
 }
+
+// cosignSigs exposes the layers of a cosign signature or attestation image as
+// cosign oci.Signatures.
+type cosignSigs struct {
+	v1.Image
+}
+
+var _ cosignoci.Signatures = (*cosignSigs)(nil)
+
+func (s *cosignSigs) Get() ([]cosignoci.Signature, error) {
+	m, err := s.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	signatures := make([]cosignoci.Signature, 0, len(m.Layers))
+	for _, desc := range m.Layers {
+		layer, err := s.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, cosignsignature.New(layer, desc))
+	}
+	return signatures, nil
+}
+
+// cosignSignatures returns the signatures held in the cosign image, from imgs,
+// that has the specified digest and suffix. If there is no such image, empty
+// signatures are returned.
+func cosignSignatures(imgs []ReferencedImage, digest v1.Hash, suffix string) (cosignoci.Signatures, error) {
+	ref, err := CosignRef(digest, nil, suffix)
+	if err != nil {
+		return nil, err
+	}
+	for _, csi := range imgs {
+		if csi.Ref == ref {
+			return &cosignSigs{Image: csi.Img}, nil
+		}
+	}
+	return cosignempty.Signatures(), nil
+}
+
+// cosignSignatureAnnotation is the annotation holding the base64 encoded
+// signature of a cosign signature layer.
+const cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// cosignSignatureKey identifies a cosign signature. Signatures of the same
+// payload, by different keys, share a layer digest but are distinct.
+type cosignSignatureKey struct {
+	digest v1.Hash
+	sig    string
+}
+
+var errAttachmentNotFound = errors.New("cosign attachment not found")
+
+// cosignAttachment returns the attachment held in the cosign image, from imgs,
+// that has the specified digest and name.
+func cosignAttachment(imgs []ReferencedImage, digest v1.Hash, name string) (cosignoci.File, error) {
+	ref, err := CosignRef(digest, nil, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, csi := range imgs {
+		if csi.Ref == ref {
+			return &cosignFile{Image: csi.Img}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", errAttachmentNotFound, ref)
+}
+
+var errAttachmentLayers = errors.New("expected exactly one layer in attachment")
+
+// cosignFile exposes the single layer of a cosign attachment image as a
+// cosign oci.File.
+type cosignFile struct {
+	v1.Image
+}
+
+var _ cosignoci.File = (*cosignFile)(nil)
+
+// layer returns the single layer, and its descriptor, of the attachment.
+func (f *cosignFile) layer() (v1.Layer, v1.Descriptor, error) {
+	m, err := f.Manifest()
+	if err != nil {
+		return nil, v1.Descriptor{}, err
+	}
+	if n := len(m.Layers); n != 1 {
+		return nil, v1.Descriptor{}, fmt.Errorf("%w, got %d", errAttachmentLayers, n)
+	}
+	l, err := f.LayerByDigest(m.Layers[0].Digest)
+	if err != nil {
+		return nil, v1.Descriptor{}, err
+	}
+	return l, m.Layers[0], nil
+}
+
+func (f *cosignFile) FileMediaType() (types.MediaType, error) {
+	_, desc, err := f.layer()
+	if err != nil {
+		return "", err
+	}
+	return desc.MediaType, nil
+}
+
+func (f *cosignFile) Payload() ([]byte, error) {
+	l, _, err := f.layer()
+	if err != nil {
+		return nil, err
+	}
+	rc, err := l.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// Signatures returns an empty set, as signatures of an attachment are not
+// discovered.
+func (f *cosignFile) Signatures() (cosignoci.Signatures, error) {
+	return cosignempty.Signatures(), nil
+}
+
+// Attestations returns an empty set, as attestations of an attachment are not
+// discovered.
+func (f *cosignFile) Attestations() (cosignoci.Signatures, error) {
+	return cosignempty.Signatures(), nil
+}
+
+// Attachment returns an error, as attachments of an attachment are not
+// discovered.
+func (f *cosignFile) Attachment(name string) (cosignoci.File, error) {
+	return nil, fmt.Errorf("%w: %v", errAttachmentNotFound, name)
+}
+
+// appendSignatures returns an image holding the layers of base, followed by
+// each of sigs that is not already held by base. If base already holds all of
+// sigs, it is returned unmodified, and false.
+func appendSignatures(base v1.Image, sigs cosignoci.Signatures) (v1.Image, bool, error) {
+	m, err := base.Manifest()
+	if err != nil {
+		return nil, false, err
+	}
+
+	have := make(map[cosignSignatureKey]bool)
+	for _, desc := range m.Layers {
+		have[cosignSignatureKey{desc.Digest, desc.Annotations[cosignSignatureAnnotation]}] = true
+	}
+
+	ss, err := sigs.Get()
+	if err != nil {
+		return nil, false, err
+	}
+
+	adds := []mutate.Addendum{}
+	for _, sig := range ss {
+		h, err := sig.Digest()
+		if err != nil {
+			return nil, false, err
+		}
+		ann, err := sig.Annotations()
+		if err != nil {
+			return nil, false, err
+		}
+
+		k := cosignSignatureKey{h, ann[cosignSignatureAnnotation]}
+		if have[k] {
+			continue
+		}
+		have[k] = true
+
+		adds = append(adds, mutate.Addendum{Layer: sig, Annotations: ann})
+	}
+	if len(adds) == 0 {
+		return base, false, nil
+	}
+
+	img, err := mutate.Append(base, adds...)
+	if err != nil {
+		return nil, false, err
+	}
+	return img, true, nil
+}
+
+// signedImage wraps a v1.Image as a cosign oci.SignedImage, using the cosign
+// images that were found alongside it in a source.
+type signedImage struct {
+	v1.Image
+	cosignImages []ReferencedImage
+}
+
+var _ cosignoci.SignedImage = (*signedImage)(nil)
+
+func (i *signedImage) Signatures() (cosignoci.Signatures, error) {
+	h, err := i.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return cosignSignatures(i.cosignImages, h, cosignremote.SignatureTagSuffix)
+}
+
+func (i *signedImage) Attestations() (cosignoci.Signatures, error) {
+	h, err := i.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return cosignSignatures(i.cosignImages, h, cosignremote.AttestationTagSuffix)
+}
+
+func (i *signedImage) Attachment(name string) (cosignoci.File, error) {
+	h, err := i.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return cosignAttachment(i.cosignImages, h, name)
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"context"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	cosignoci "github.com/sigstore/cosign/v2/pkg/oci"
+	cosignremote "github.com/sigstore/cosign/v2/pkg/oci/remote"
+)
+
+var _ SignedDescriptor = &ociDescriptor{}
+
+// CosignImages checks for image manifests providing cosign signatures,
+// attestations & attachments (such as SBOMs) that are associated with the
+// image or index with the descriptor, in the OCI layout.
+//
+// If recursive is true, then if the descriptor is an index, we also check for
+// signatures, attestations and attachments for each of its associated
+// manifests.
+//
+// The images are referenced as '_cosign:<tag>', where <tag> matches the tag at
+// src. The '_cosign' repository placeholder string is used instead of any
+// original registry & repository names.
+func (d *ociDescriptor) CosignImages(_ context.Context, recursive bool) ([]ReferencedImage, error) {
+	targets, err := cosignTargets(d, d.descriptor.Digest, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	ri, err := d.path.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	im, err := ri.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	return cosignImages(targets, refNames(im.Manifests), func(ref name.Reference) (v1.Image, error) {
+		ims, err := partial.FindImages(ri, match.Name(ref.Name()))
+		if err != nil || len(ims) == 0 {
+			return nil, err
+		}
+		if len(ims) > 1 {
+			return nil, ErrMultipleManifests
+		}
+		return ims[0], nil
+	})
+}
+
+// SignedImage returns an image Descriptor as a cosign oci.SignedImage, allowing
+// access to signatures, attestations and attachments stored alongside the image
+// in the OCI layout.
+func (d *ociDescriptor) SignedImage(ctx context.Context) (cosignoci.SignedImage, error) {
+	img, err := d.Image()
+	if err != nil {
+		return nil, err
+	}
+
+	cosignImages, err := d.CosignImages(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signedImage{
+		Image:        img,
+		cosignImages: cosignImages,
+	}, nil
+}
+
+// SignedImageIndex returns an image index Descriptor as a cosign
+// oci.SignedImageIndex, allowing access to signatures, attestations and
+// attachments stored alongside the image in the OCI layout.
+func (d *ociDescriptor) SignedImageIndex(ctx context.Context) (cosignoci.SignedImageIndex, error) {
+	if !d.MediaType().IsIndex() {
+		return nil, ErrUnsupportedMediaType
+	}
+	idx, err := d.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	cosignImages, err := d.CosignImages(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ociSignedImageIndex{
+		v1Index:      idx,
+		path:         d.path,
+		cosignImages: cosignImages,
+	}, nil
+}
+
+type ociSignedImageIndex struct {
+	v1Index
+	path         layout.Path
+	cosignImages []ReferencedImage
+}
+
+var _ cosignoci.SignedImageIndex = (*ociSignedImageIndex)(nil)
+
+func (i *ociSignedImageIndex) Signatures() (cosignoci.Signatures, error) {
+	h, err := i.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return cosignSignatures(i.cosignImages, h, cosignremote.SignatureTagSuffix)
+}
+
+func (i *ociSignedImageIndex) Attestations() (cosignoci.Signatures, error) {
+	h, err := i.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return cosignSignatures(i.cosignImages, h, cosignremote.AttestationTagSuffix)
+}
+
+func (i *ociSignedImageIndex) SignedImage(h v1.Hash) (cosignoci.SignedImage, error) {
+	img, err := i.Image(h)
+	if err != nil {
+		return nil, err
+	}
+	d, err := partial.Descriptor(img)
+	if err != nil {
+		return nil, err
+	}
+	mf, err := img.RawManifest()
+	if err != nil {
+		return nil, err
+	}
+	od := &ociDescriptor{
+		descriptor: *d,
+		Manifest:   mf,
+		path:       i.path,
+	}
+	return od.SignedImage(context.Background())
+}
+
+func (i *ociSignedImageIndex) SignedImageIndex(h v1.Hash) (cosignoci.SignedImageIndex, error) {
+	ii, err := i.ImageIndex(h)
+	if err != nil {
+		return nil, err
+	}
+	d, err := partial.Descriptor(ii)
+	if err != nil {
+		return nil, err
+	}
+	mf, err := ii.RawManifest()
+	if err != nil {
+		return nil, err
+	}
+	od := &ociDescriptor{
+		descriptor: *d,
+		Manifest:   mf,
+		path:       i.path,
+	}
+	return od.SignedImageIndex(context.Background())
+}
+
+func (i *ociSignedImageIndex) Attachment(name string) (cosignoci.File, error) {
+	h, err := i.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return cosignAttachment(i.cosignImages, h, name)
+}
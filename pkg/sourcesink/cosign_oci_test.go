@@ -0,0 +1,197 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+func Test_ociDescriptor_CosignImages(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		recursive bool
+		wantCount int
+	}{
+		{
+			name:      "Image",
+			src:       corpus.ImagePath("hello-world-cosign-manifest"),
+			recursive: false,
+			wantCount: 2, // 1 signature, 1 attestation against image
+		},
+		{
+			name:      "IndexOnly",
+			src:       corpus.ImagePath("hello-world-cosign-manifest-list"),
+			recursive: false,
+			wantCount: 2, // 1 signature, 1 attestation against index
+		},
+		{
+			name:      "IndexRecursive",
+			src:       corpus.ImagePath("hello-world-cosign-manifest-list"),
+			recursive: true,
+			wantCount: 11, // 1 signature, 1 attestation against index + 1 signature against each referenced image (9 total)
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := OCIFromPath(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sd, ok := d.(SignedDescriptor)
+			if !ok {
+				t.Fatal("could not upgrade Descriptor to SignedDescriptor")
+			}
+
+			got, err := sd.CosignImages(t.Context(), tt.recursive)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != tt.wantCount {
+				t.Errorf("Got %d cosign images, expected %d", len(got), tt.wantCount)
+			}
+		})
+	}
+}
+
+//nolint:dupl
+func Test_ociDescriptor_SignedImage(t *testing.T) {
+	tests := []struct {
+		name             string
+		src              string
+		wantSignatures   int
+		wantAttestations int
+	}{
+		{
+			name:             "UnsignedImage",
+			src:              corpus.ImagePath("hello-world-docker-v2-manifest"),
+			wantSignatures:   0,
+			wantAttestations: 0,
+		},
+		{
+			name:             "SignedImage",
+			src:              corpus.ImagePath("hello-world-cosign-manifest"),
+			wantSignatures:   1,
+			wantAttestations: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := OCIFromPath(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sd, ok := d.(SignedDescriptor)
+			if !ok {
+				t.Fatal("could not upgrade Descriptor to SignedDescriptor")
+			}
+
+			si, err := sd.SignedImage(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkSignedImage(t, si, tt.wantSignatures, tt.wantAttestations)
+		})
+	}
+}
+
+//nolint:dupl
+func Test_ociDescriptor_SignedImageIndex(t *testing.T) {
+	tests := []struct {
+		name             string
+		src              string
+		wantSignatures   int
+		wantAttestations int
+	}{
+		{
+			name:             "UnsignedIndex",
+			src:              corpus.ImagePath("hello-world-docker-v2-manifest-list"),
+			wantSignatures:   0,
+			wantAttestations: 0,
+		},
+		{
+			name:             "SignedIndex",
+			src:              corpus.ImagePath("hello-world-cosign-manifest-list"),
+			wantSignatures:   1,
+			wantAttestations: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := OCIFromPath(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sd, ok := d.(SignedDescriptor)
+			if !ok {
+				t.Fatal("could not upgrade Descriptor to SignedDescriptor")
+			}
+
+			sii, err := sd.SignedImageIndex(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkSignedImageIndex(t, sii, tt.wantSignatures, tt.wantAttestations)
+		})
+	}
+}
+
+func Test_ociDescriptor_Attachment(t *testing.T) {
+	imgDigest := v1.Hash{Algorithm: "sha256", Hex: "432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338"}
+
+	s, err := OCIEmpty(filepath.Join(t.TempDir(), "layout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(t.Context(), corpus.Image(t, "hello-world-docker-v2-manifest")); err != nil {
+		t.Fatal(err)
+	}
+	writeTestAttachments(t, s, imgDigest)
+
+	for _, tt := range attachmentTests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 1 SBOM, 1 named attachment.
+			imgs, err := d.(SignedDescriptor).CosignImages(t.Context(), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(imgs), 2; got != want {
+				t.Errorf("got %v cosign images, want %v", got, want)
+			}
+
+			si, err := d.(SignedDescriptor).SignedImage(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkAttachment(t, si, tt.attachment, tt.wantErr, tt.wantPayload, tt.wantMT)
+		})
+	}
+}
@@ -1,82 +1,63 @@
-// Copyright 2024-2025 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
 package sourcesink
 
 import (
-	"bytes"
 	"context"
 	"errors"
-	"log/slog"
+	"fmt"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/match"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
 	cosignoci "github.com/sigstore/cosign/v2/pkg/oci"
 	cosignempty "github.com/sigstore/cosign/v2/pkg/oci/empty"
 	cosignremote "github.com/sigstore/cosign/v2/pkg/oci/remote"
-	cosignsignature "github.com/sigstore/cosign/v2/pkg/oci/signature"
 	"github.com/sylabs/oci-tools/pkg/sif"
+	ssif "github.com/sylabs/sif/v2/pkg/sif"
 )
 
 var _ SignedDescriptor = &sifDescriptor{}
 
-// CosignImages checks for image manifests providing cosign signatures &
-// attestations that are associated with the image or index with the descriptor
-// If image manifests providing cosign signatures and / or attestations
-// exist, then these images are returned in a name.Reference -> v1.Image map.
+// CosignImages checks for image manifests providing cosign signatures,
+// attestations & attachments (such as SBOMs) that are associated with the
+// image or index with the descriptor. If image manifests providing cosign
+// signatures, attestations and / or attachments exist, then these images are
+// returned in a name.Reference -> v1.Image map.
 //
 // If recursive is true, then if the descriptor is an index, we also check for
-// signatures and attestations for each of its associated manifests.
+// signatures, attestations and attachments for each of its associated
+// manifests.
 //
 // In the returned map, the images are referenced as '_cosign:<tag>', where
 // <tag> matches the tag at src. The '_cosign' repository placeholder string
 // is used instead of any original registry & repository names.
 func (d *sifDescriptor) CosignImages(_ context.Context, recursive bool) ([]ReferencedImage, error) {
-	csImgs := []ReferencedImage{}
-
-	// Targets lists digests to check for the existence of associated signatures / images.
-	targets := []v1.Hash{d.descriptor.Digest}
+	targets, err := cosignTargets(d, d.descriptor.Digest, recursive)
+	if err != nil {
+		return nil, err
+	}
 
-	if d.MediaType().IsIndex() && recursive {
-		rmf, err := d.RawManifest()
-		if err != nil {
-			return nil, err
-		}
-		mf, err := v1.ParseIndexManifest(bytes.NewBuffer(rmf))
-		if err != nil {
-			return nil, err
-		}
-		for _, m := range mf.Manifests {
-			targets = append(targets, m.Digest)
-		}
+	ds, err := d.ofi.FindManifests(nil)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, target := range targets {
-		for _, suffix := range cosignSuffixes {
-			csRef, err := CosignRef(target, nil, suffix)
-			if err != nil {
-				return nil, err
-			}
-			slog.Debug("checking for cosign image", slog.String("ref", csRef.Name()))
-			csImg, err := d.ofi.Image(match.Name(csRef.Name()))
-			if err == nil {
-				slog.Debug("found cosign image", slog.String("ref", csRef.Name()))
-				csImgs = append(csImgs, ReferencedImage{Ref: csRef, Img: csImg})
-				continue
-			}
-			if errors.Is(err, sif.ErrNoMatch) {
-				continue
-			}
-			return nil, err
+	return cosignImages(targets, refNames(ds), func(ref name.Reference) (v1.Image, error) {
+		img, err := d.ofi.Image(match.Name(ref.Name()))
+		if errors.Is(err, sif.ErrNoMatch) {
+			return nil, nil
 		}
-	}
-	return csImgs, nil
+		return img, err
+	})
 }
 
 // SignedImage returns an image Descriptor as a cosign oci.SignedImage, allowing
-// access to signatures and attestations stored alongside the image in the SIF.
+// access to signatures, attestations and attachments stored alongside the image
+// in the SIF.
 func (d *sifDescriptor) SignedImage(ctx context.Context) (cosignoci.SignedImage, error) {
 	img, err := d.Image()
 	if err != nil {
@@ -88,79 +69,15 @@ func (d *sifDescriptor) SignedImage(ctx context.Context) (cosignoci.SignedImage,
 		return nil, err
 	}
 
-	return &sifSignedimage{
+	return &signedImage{
 		Image:        img,
 		cosignImages: cosignImages,
 	}, nil
 }
 
-type sifSigs struct {
-	v1.Image
-}
-
-var _ cosignoci.Signatures = (*sifSigs)(nil)
-
-func (s *sifSigs) Get() ([]cosignoci.Signature, error) {
-	m, err := s.Manifest()
-	if err != nil {
-		return nil, err
-	}
-	signatures := make([]cosignoci.Signature, 0, len(m.Layers))
-	for _, desc := range m.Layers {
-		layer, err := s.LayerByDigest(desc.Digest)
-		if err != nil {
-			return nil, err
-		}
-		signatures = append(signatures, cosignsignature.New(layer, desc))
-	}
-	return signatures, nil
-}
-
-type sifSignedimage struct {
-	v1.Image
-	cosignImages []ReferencedImage
-}
-
-var _ cosignoci.SignedImage = (*sifSignedimage)(nil)
-
-func (i *sifSignedimage) Signatures() (cosignoci.Signatures, error) {
-	h, err := i.Digest()
-	if err != nil {
-		return nil, err
-	}
-	return i.signatures(h, cosignremote.SignatureTagSuffix)
-}
-
-func (i *sifSignedimage) Attestations() (cosignoci.Signatures, error) {
-	h, err := i.Digest()
-	if err != nil {
-		return nil, err
-	}
-	return i.signatures(h, cosignremote.AttestationTagSuffix)
-}
-
-func (i *sifSignedimage) signatures(digest v1.Hash, suffix string) (cosignoci.Signatures, error) {
-	ref, err := CosignRef(digest, nil, suffix)
-	if err != nil {
-		return nil, err
-	}
-	for _, csi := range i.cosignImages {
-		if csi.Ref == ref {
-			return &sifSigs{Image: csi.Img}, nil
-		}
-	}
-	return cosignempty.Signatures(), nil
-}
-
-var errUnsupportedAttachment = errors.New("cosign attachments are not supported")
-
-func (i *sifSignedimage) Attachment(_ string) (cosignoci.File, error) {
-	return nil, errUnsupportedAttachment
-}
-
 // SignedImageIndex returns an image index Descriptor as a cosign
-// oci.SignedImageIndex, allowing access to signatures and attestations stored
-// alongside the image in the SIF.
+// oci.SignedImageIndex, allowing access to signatures, attestations and
+// attachments stored alongside the image in the SIF.
 func (d *sifDescriptor) SignedImageIndex(ctx context.Context) (cosignoci.SignedImageIndex, error) {
 	if !d.MediaType().IsIndex() {
 		return nil, ErrUnsupportedMediaType
@@ -197,7 +114,7 @@ func (i *sifSignedImageIndex) Signatures() (cosignoci.Signatures, error) {
 	if err != nil {
 		return nil, err
 	}
-	return i.signatures(h, cosignremote.SignatureTagSuffix)
+	return cosignSignatures(i.cosignImages, h, cosignremote.SignatureTagSuffix)
 }
 
 func (i *sifSignedImageIndex) Attestations() (cosignoci.Signatures, error) {
@@ -205,7 +122,7 @@ func (i *sifSignedImageIndex) Attestations() (cosignoci.Signatures, error) {
 	if err != nil {
 		return nil, err
 	}
-	return i.signatures(h, cosignremote.AttestationTagSuffix)
+	return cosignSignatures(i.cosignImages, h, cosignremote.AttestationTagSuffix)
 }
 
 func (i *sifSignedImageIndex) SignedImage(h v1.Hash) (cosignoci.SignedImage, error) {
@@ -260,19 +177,60 @@ func (i *sifSignedImageIndex) SignedImageIndex(h v1.Hash) (cosignoci.SignedImage
 	return sd.SignedImageIndex(context.Background())
 }
 
-func (i *sifSignedImageIndex) signatures(digest v1.Hash, suffix string) (cosignoci.Signatures, error) {
-	ref, err := CosignRef(digest, nil, suffix)
+func (i *sifSignedImageIndex) Attachment(name string) (cosignoci.File, error) {
+	h, err := i.Digest()
 	if err != nil {
 		return nil, err
 	}
-	for _, csi := range i.cosignImages {
-		if csi.Ref == ref {
-			return &sifSigs{Image: csi.Img}, nil
-		}
-	}
-	return cosignempty.Signatures(), nil
+	return cosignAttachment(i.cosignImages, h, name)
+}
+
+var _ CosignWriter = &sifSourceSink{}
+
+// WriteSignatures stores sigs as cosign signatures for the image or index with
+// digest h in the SIF file, merging them with any signatures already held for
+// h. The signatures are stored in an image with a '_cosign' placeholder
+// reference.
+func (o *sifSourceSink) WriteSignatures(_ context.Context, h v1.Hash, sigs cosignoci.Signatures) error {
+	return o.writeCosign(h, sigs, cosignremote.SignatureTagSuffix)
 }
 
-func (i *sifSignedImageIndex) Attachment(_ string) (cosignoci.File, error) {
-	return nil, errUnsupportedAttachment
+// WriteAttestations stores atts as cosign attestations for the image or index
+// with digest h in the SIF file, merging them with any attestations already
+// held for h. The attestations are stored in an image with a '_cosign'
+// placeholder reference.
+func (o *sifSourceSink) WriteAttestations(_ context.Context, h v1.Hash, atts cosignoci.Signatures) error {
+	return o.writeCosign(h, atts, cosignremote.AttestationTagSuffix)
+}
+
+// writeCosign merges sigs into the cosign image with the specified suffix, for
+// the image or index with digest h, creating the cosign image if necessary.
+func (o *sifSourceSink) writeCosign(h v1.Hash, sigs cosignoci.Signatures, suffix string) error {
+	// The image or index must be held in the SIF, though it need not be
+	// referenced directly from the root index.
+	if _, err := o.ofi.Offset(h); errors.Is(err, ssif.ErrObjectNotFound) {
+		return fmt.Errorf("%w: %v", ErrNoManifest, h)
+	} else if err != nil {
+		return err
+	}
+
+	ref, err := CosignRef(h, nil, suffix)
+	if err != nil {
+		return err
+	}
+	m := match.Name(ref.Name())
+
+	var base v1.Image = cosignempty.Signatures()
+	if img, err := o.ofi.Image(m); err == nil {
+		base = img
+	} else if !errors.Is(err, sif.ErrNoMatch) {
+		return err
+	}
+
+	img, changed, err := appendSignatures(base, sigs)
+	if err != nil || !changed {
+		return err
+	}
+
+	return o.ofi.ReplaceImage(img, m, sif.OptAppendReference(ref))
 }
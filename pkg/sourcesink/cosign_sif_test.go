@@ -1,13 +1,22 @@
-// Copyright 2024-2025 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
 package sourcesink
 
 import (
+	"errors"
+	"maps"
 	"testing"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	cosignoci "github.com/sigstore/cosign/v2/pkg/oci"
+	cosignempty "github.com/sigstore/cosign/v2/pkg/oci/empty"
+	ocisif "github.com/sylabs/oci-tools/pkg/sif"
 )
 
 func Test_sifDescriptor_CosignImages(t *testing.T) {
@@ -211,3 +220,323 @@ func checkSignedImageIndex(t *testing.T, sii cosignoci.SignedImageIndex, wantSig
 		t.Errorf("Got %d cosign attestations, expected %d", len(atts), wantAtts)
 	}
 }
+
+// testSignatures returns cosign signatures holding a single layer, with the
+// specified payload and base64 encoded signature.
+func testSignatures(t *testing.T, payload, sig string) cosignoci.Signatures {
+	t.Helper()
+
+	return annotatedSignatures(t, payload, sig, nil)
+}
+
+// annotatedSignatures returns cosign signatures holding a single simple
+// signing payload, with base64 encoded signature sig, and the specified
+// additional annotations.
+func annotatedSignatures(t *testing.T, payload, sig string, annotations map[string]string) cosignoci.Signatures {
+	t.Helper()
+
+	anns := maps.Clone(annotations)
+	if anns == nil {
+		anns = make(map[string]string)
+	}
+	anns[cosignSignatureAnnotation] = sig
+
+	l := static.NewLayer([]byte(payload), "application/vnd.dev.cosign.simplesigning.v1+json")
+	img, err := mutate.Append(cosignempty.Signatures(), mutate.Addendum{
+		Layer:       l,
+		Annotations: anns,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cosignSigs{Image: img}
+}
+
+// existingSignatures returns the cosign signatures held for the image at src.
+func existingSignatures(t *testing.T, src string) cosignoci.Signatures {
+	t.Helper()
+
+	s, err := SIFFromPath(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := s.Get(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := d.(SignedDescriptor).SignedImage(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs, err := si.Signatures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sigs
+}
+
+func Test_sifSourceSink_WriteCosign(t *testing.T) {
+	imgDigest := v1.Hash{Algorithm: "sha256", Hex: "432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338"}
+	signedSrc := corpus.SIF(t, "hello-world-cosign-manifest")
+
+	tests := []struct {
+		name             string
+		src              string
+		digest           v1.Hash
+		sigs             cosignoci.Signatures
+		more             cosignoci.Signatures
+		atts             cosignoci.Signatures
+		wantErr          error
+		wantSignatures   int
+		wantAttestations int
+	}{
+		{
+			name:             "UnsignedImageSignature",
+			src:              "hello-world-docker-v2-manifest",
+			digest:           imgDigest,
+			sigs:             testSignatures(t, "sig", "c2lnbmF0dXJl"),
+			wantSignatures:   1,
+			wantAttestations: 0,
+		},
+		{
+			name:             "UnsignedImageAttestation",
+			src:              "hello-world-docker-v2-manifest",
+			digest:           imgDigest,
+			atts:             testSignatures(t, "att", "c2lnbmF0dXJl"),
+			wantSignatures:   0,
+			wantAttestations: 1,
+		},
+		{
+			name:             "SignedImageMerge",
+			src:              "hello-world-cosign-manifest",
+			digest:           imgDigest,
+			sigs:             testSignatures(t, "sig", "c2lnbmF0dXJl"),
+			atts:             testSignatures(t, "att", "c2lnbmF0dXJl"),
+			wantSignatures:   2,
+			wantAttestations: 2,
+		},
+		{
+			name:             "SignedImageSamePayload",
+			src:              "hello-world-cosign-manifest",
+			digest:           imgDigest,
+			sigs:             testSignatures(t, "sig", "c2lnbmF0dXJl"),
+			more:             testSignatures(t, "sig", "b3RoZXI="),
+			wantSignatures:   3,
+			wantAttestations: 1,
+		},
+		{
+			name:             "SignedImageExisting",
+			src:              "hello-world-cosign-manifest",
+			digest:           imgDigest,
+			sigs:             existingSignatures(t, signedSrc),
+			wantSignatures:   1,
+			wantAttestations: 1,
+		},
+		{
+			name:             "BadDigest",
+			src:              "hello-world-docker-v2-manifest",
+			digest:           v1.Hash{Algorithm: "sha256", Hex: "0000000000000000000000000000000000000000000000000000000000000000"},
+			sigs:             testSignatures(t, "sig", "c2lnbmF0dXJl"),
+			wantErr:          ErrNoManifest,
+			wantSignatures:   0,
+			wantAttestations: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := corpus.SIF(t, tt.src, ocisif.OptWriteWithSpareDescriptorCapacity(8))
+
+			s, err := SIFFromPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cw, ok := s.(CosignWriter)
+			if !ok {
+				t.Fatalf("%T is not a CosignWriter", s)
+			}
+
+			if tt.sigs != nil {
+				if err := cw.WriteSignatures(t.Context(), tt.digest, tt.sigs); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("WriteSignatures() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+			if tt.more != nil {
+				if err := cw.WriteSignatures(t.Context(), tt.digest, tt.more); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("WriteSignatures() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+			if tt.atts != nil {
+				if err := cw.WriteAttestations(t.Context(), tt.digest, tt.atts); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("WriteAttestations() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+
+			s, err = SIFFromPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			si, err := d.(SignedDescriptor).SignedImage(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkSignedImage(t, si, tt.wantSignatures, tt.wantAttestations)
+		})
+	}
+}
+
+// testAttachment returns a cosign attachment image holding a single layer,
+// with the specified payload and media type.
+func testAttachment(t *testing.T, payload string, mt types.MediaType) v1.Image {
+	t.Helper()
+
+	img, err := mutate.AppendLayers(
+		mutate.MediaType(empty.Image, types.OCIManifestSchema1),
+		static.NewLayer([]byte(payload), mt),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// writeTestAttachments writes an SBOM, and a named attachment, for the image
+// or index with digest h, to s.
+func writeTestAttachments(t *testing.T, s Sink, h v1.Hash) {
+	t.Helper()
+
+	for _, a := range []struct {
+		name    string
+		payload string
+		mt      types.MediaType
+	}{
+		{"sbom", `{"spdxVersion":"SPDX-2.3"}`, "text/spdx+json"},
+		{"readme", "hello", "text/plain"},
+	} {
+		ref, err := CosignRef(h, nil, a.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Write(t.Context(), testAttachment(t, a.payload, a.mt), WriteWithReference(ref)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkAttachment verifies that the named attachment of se has the expected
+// payload and media type.
+func checkAttachment(t *testing.T, se cosignoci.SignedEntity, name string, wantErr error, wantPayload string, wantMT types.MediaType) {
+	t.Helper()
+
+	f, err := se.Attachment(name)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("Attachment() error = %v, wantErr %v", err, wantErr)
+	}
+	if wantErr != nil {
+		return
+	}
+
+	mt, err := f.FileMediaType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mt, wantMT; got != want {
+		t.Errorf("got media type %v, want %v", got, want)
+	}
+
+	p, err := f.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(p), wantPayload; got != want {
+		t.Errorf("got payload %q, want %q", got, want)
+	}
+}
+
+//nolint:gochecknoglobals
+var attachmentTests = []struct {
+	name        string
+	attachment  string
+	wantErr     error
+	wantPayload string
+	wantMT      types.MediaType
+}{
+	{
+		name:        "SBOM",
+		attachment:  "sbom",
+		wantPayload: `{"spdxVersion":"SPDX-2.3"}`,
+		wantMT:      "text/spdx+json",
+	},
+	{
+		name:        "Named",
+		attachment:  "readme",
+		wantPayload: "hello",
+		wantMT:      "text/plain",
+	},
+	{
+		name:       "Missing",
+		attachment: "missing",
+		wantErr:    errAttachmentNotFound,
+	},
+}
+
+func Test_sifDescriptor_Attachment(t *testing.T) {
+	imgDigest := v1.Hash{Algorithm: "sha256", Hex: "432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338"}
+	idxDigest := v1.Hash{Algorithm: "sha256", Hex: "00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed"}
+
+	imgPath := corpus.SIF(t, "hello-world-cosign-manifest", ocisif.OptWriteWithSpareDescriptorCapacity(8))
+	imgSrc, err := SIFFromPath(imgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestAttachments(t, imgSrc, imgDigest)
+
+	idxPath := corpus.SIF(t, "hello-world-cosign-manifest-list", ocisif.OptWriteWithSpareDescriptorCapacity(8))
+	idxSrc, err := SIFFromPath(idxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestAttachments(t, idxSrc, idxDigest)
+
+	for _, tt := range attachmentTests {
+		t.Run("Image"+tt.name, func(t *testing.T) {
+			d, err := imgSrc.Get(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 1 signature, 1 attestation, 1 SBOM, 1 named attachment.
+			imgs, err := d.(SignedDescriptor).CosignImages(t.Context(), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(imgs), 4; got != want {
+				t.Errorf("got %v cosign images, want %v", got, want)
+			}
+
+			si, err := d.(SignedDescriptor).SignedImage(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkAttachment(t, si, tt.attachment, tt.wantErr, tt.wantPayload, tt.wantMT)
+		})
+
+		t.Run("Index"+tt.name, func(t *testing.T) {
+			d, err := idxSrc.Get(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sii, err := d.(SignedDescriptor).SignedImageIndex(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkAttachment(t, sii, tt.attachment, tt.wantErr, tt.wantPayload, tt.wantMT)
+		})
+	}
+}
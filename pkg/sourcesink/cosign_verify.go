@@ -0,0 +1,236 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	cosignoci "github.com/sigstore/cosign/v2/pkg/oci"
+	cosignremote "github.com/sigstore/cosign/v2/pkg/oci/remote"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/tuf"
+)
+
+var (
+	// ErrNoSignatures is returned when no cosign signatures are found to verify.
+	ErrNoSignatures = errors.New("no cosign signatures found")
+
+	errVerifyNoKey = errors.New("a public key or certificates must be provided to verify signatures")
+)
+
+// verifyOpts holds options that apply to a VerifySignatures operation.
+type verifyOpts struct {
+	recursive        bool
+	publicKey        crypto.PublicKey
+	roots            []*x509.Certificate
+	intermediates    []*x509.Certificate
+	identities       []cosign.Identity
+	rekorPubKeys     [][]byte
+	tsaRoots         []*x509.Certificate
+	tsaIntermediates []*x509.Certificate
+}
+
+// VerifyOpt sets an option that applies to a VerifySignatures operation.
+type VerifyOpt func(*verifyOpts) error
+
+// VerifyWithPublicKey sets the public key that signatures must be verifiable
+// with.
+func VerifyWithPublicKey(pub crypto.PublicKey) VerifyOpt {
+	return func(o *verifyOpts) error {
+		o.publicKey = pub
+		return nil
+	}
+}
+
+// VerifyWithCertificates sets the root, and optional intermediate,
+// certificates that the signing certificate of each signature must chain to.
+func VerifyWithCertificates(roots, intermediates []*x509.Certificate) VerifyOpt {
+	return func(o *verifyOpts) error {
+		o.roots = roots
+		o.intermediates = intermediates
+		return nil
+	}
+}
+
+// VerifyWithIdentities sets the identities, one of which the signing
+// certificate of each signature must match. It applies only when verifying
+// with certificates.
+func VerifyWithIdentities(ids ...cosign.Identity) VerifyOpt {
+	return func(o *verifyOpts) error {
+		o.identities = ids
+		return nil
+	}
+}
+
+// VerifyWithRekorPublicKeys sets the PEM encoded public keys of trusted Rekor
+// transparency logs. If set, each signature must carry a Rekor bundle, which
+// is verified against these keys. By default, transparency log evidence is
+// not verified.
+func VerifyWithRekorPublicKeys(pems ...[]byte) VerifyOpt {
+	return func(o *verifyOpts) error {
+		o.rekorPubKeys = pems
+		return nil
+	}
+}
+
+// VerifyWithTSACertificates sets the root, and optional intermediate,
+// certificates of trusted timestamp authorities. If set, any RFC3161 timestamp
+// carried by a signature is verified against these certificates, and the
+// expiry of a signing certificate is checked at the time of the timestamp,
+// rather than the current time.
+func VerifyWithTSACertificates(roots, intermediates []*x509.Certificate) VerifyOpt {
+	return func(o *verifyOpts) error {
+		o.tsaRoots = roots
+		o.tsaIntermediates = intermediates
+		return nil
+	}
+}
+
+// VerifyWithRecursive sets whether, when verifying an index, signatures
+// associated with each of its manifests are also verified. By default, they
+// are not.
+func VerifyWithRecursive(b bool) VerifyOpt {
+	return func(o *verifyOpts) error {
+		o.recursive = b
+		return nil
+	}
+}
+
+// checkOpts returns the cosign options used to verify signatures, according
+// to vo. Verification never requires network access.
+func (vo verifyOpts) checkOpts() (*cosign.CheckOpts, error) {
+	co := cosign.CheckOpts{
+		ClaimVerifier:               cosign.SimpleClaimVerifier,
+		Identities:                  vo.identities,
+		IgnoreSCT:                   true,
+		IgnoreTlog:                  len(vo.rekorPubKeys) == 0,
+		TSARootCertificates:         vo.tsaRoots,
+		TSAIntermediateCertificates: vo.tsaIntermediates,
+		UseSignedTimestamps:         len(vo.tsaRoots) > 0,
+		Offline:                     true,
+	}
+
+	switch {
+	case vo.publicKey != nil:
+		v, err := signature.LoadVerifier(vo.publicKey, crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		co.SigVerifier = v
+	case len(vo.roots) > 0:
+		co.RootCerts = x509.NewCertPool()
+		for _, c := range vo.roots {
+			co.RootCerts.AddCert(c)
+		}
+		if len(vo.intermediates) > 0 {
+			co.IntermediateCerts = x509.NewCertPool()
+			for _, c := range vo.intermediates {
+				co.IntermediateCerts.AddCert(c)
+			}
+		}
+	default:
+		return nil, errVerifyNoKey
+	}
+
+	if len(vo.rekorPubKeys) > 0 {
+		keys := cosign.NewTrustedTransparencyLogPubKeys()
+		for _, pem := range vo.rekorPubKeys {
+			if err := keys.AddTransparencyLogPubKey(pem, tuf.Active); err != nil {
+				return nil, err
+			}
+		}
+		co.RekorPubKeys = &keys
+	}
+
+	return &co, nil
+}
+
+// SignatureVerification describes the result of verifying a single cosign
+// signature.
+type SignatureVerification struct {
+	// Reference is the reference of the cosign image holding the signature.
+	Reference name.Reference
+	// Digest is the digest of the image or index that the signature applies to.
+	Digest v1.Hash
+	// Signature is the signature that was verified.
+	Signature cosignoci.Signature
+	// BundleVerified is true if a Rekor bundle carried by the signature was
+	// verified.
+	BundleVerified bool
+	// Err is nil if the signature was verified, or describes why verification
+	// failed.
+	Err error
+}
+
+// VerifySignatures verifies the cosign signatures, found via CosignImages, of
+// the image or index described by d. A public key, or certificates, must be
+// provided using VerifyWithPublicKey or VerifyWithCertificates. Each signature
+// is checked against the key or certificates, and its payload must specify
+// the digest of the image or index that it is stored against.
+//
+// A SignatureVerification is returned for each signature, whether or not it
+// was verified. If no signatures are found, ErrNoSignatures is returned.
+// Verification is performed offline, so evidence from a transparency log or
+// timestamp authority is only verified if it is bundled with a signature, and
+// trusted keys or certificates are provided using VerifyWithRekorPublicKeys or
+// VerifyWithTSACertificates.
+func VerifySignatures(ctx context.Context, d SignedDescriptor, opts ...VerifyOpt) ([]SignatureVerification, error) {
+	vo := verifyOpts{}
+	for _, opt := range opts {
+		if err := opt(&vo); err != nil {
+			return nil, err
+		}
+	}
+
+	co, err := vo.checkOpts()
+	if err != nil {
+		return nil, err
+	}
+
+	imgs, err := d.CosignImages(ctx, vo.recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	svs := []SignatureVerification{}
+
+	for _, ri := range imgs {
+		h, suffix, err := parseCosignTag(ri.Ref.Identifier())
+		if err != nil {
+			return nil, err
+		}
+		if suffix != cosignremote.SignatureTagSuffix {
+			continue
+		}
+
+		sigs, err := (&cosignSigs{Image: ri.Img}).Get()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sig := range sigs {
+			bundleVerified, err := cosign.VerifyImageSignature(ctx, sig, h, co)
+			svs = append(svs, SignatureVerification{
+				Reference:      ri.Ref,
+				Digest:         h,
+				Signature:      sig,
+				BundleVerified: bundleVerified,
+				Err:            err,
+			})
+		}
+	}
+
+	if len(svs) == 0 {
+		return nil, ErrNoSignatures
+	}
+
+	return svs, nil
+}
@@ -0,0 +1,468 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/digitorus/timestamp"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/cosign/v2/pkg/cosign/bundle"
+	cosignoci "github.com/sigstore/cosign/v2/pkg/oci"
+	cosignstatic "github.com/sigstore/cosign/v2/pkg/oci/static"
+	ocisif "github.com/sylabs/oci-tools/pkg/sif"
+)
+
+// signedSignatures returns cosign signatures holding a single simple signing
+// payload for digest h, signed with key.
+func signedSignatures(t *testing.T, key *ecdsa.PrivateKey, h v1.Hash) cosignoci.Signatures {
+	t.Helper()
+
+	payload := fmt.Sprintf(`{"critical":{"identity":{"docker-reference":""},`+
+		`"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, h)
+
+	sum := sha256.Sum256([]byte(payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testSignatures(t, payload, base64.StdEncoding.EncodeToString(sig))
+}
+
+func TestVerifySignatures(t *testing.T) {
+	imgDigest := v1.Hash{Algorithm: "sha256", Hex: "432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338"}
+	otherDigest := v1.Hash{Algorithm: "sha256", Hex: "00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed"}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&otherKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	tests := []struct {
+		name         string
+		sigs         []cosignoci.Signatures
+		opts         []VerifyOpt
+		wantErr      error
+		wantResults  int
+		wantVerified int
+	}{
+		{
+			name:         "Verified",
+			sigs:         []cosignoci.Signatures{signedSignatures(t, key, imgDigest)},
+			opts:         []VerifyOpt{VerifyWithPublicKey(&key.PublicKey)},
+			wantResults:  1,
+			wantVerified: 1,
+		},
+		{
+			name:         "WrongKey",
+			sigs:         []cosignoci.Signatures{signedSignatures(t, key, imgDigest)},
+			opts:         []VerifyOpt{VerifyWithPublicKey(&otherKey.PublicKey)},
+			wantResults:  1,
+			wantVerified: 0,
+		},
+		{
+			name:         "DigestMismatch",
+			sigs:         []cosignoci.Signatures{signedSignatures(t, key, otherDigest)},
+			opts:         []VerifyOpt{VerifyWithPublicKey(&key.PublicKey)},
+			wantResults:  1,
+			wantVerified: 0,
+		},
+		{
+			name: "Mixed",
+			sigs: []cosignoci.Signatures{
+				signedSignatures(t, key, imgDigest),
+				signedSignatures(t, otherKey, imgDigest),
+			},
+			opts:         []VerifyOpt{VerifyWithPublicKey(&key.PublicKey)},
+			wantResults:  2,
+			wantVerified: 1,
+		},
+		{
+			name: "RekorBundleMissing",
+			sigs: []cosignoci.Signatures{signedSignatures(t, key, imgDigest)},
+			opts: []VerifyOpt{
+				VerifyWithPublicKey(&key.PublicKey),
+				VerifyWithRekorPublicKeys(rekorPEM),
+			},
+			wantResults:  1,
+			wantVerified: 0,
+		},
+		{
+			name:    "Unsigned",
+			opts:    []VerifyOpt{VerifyWithPublicKey(&key.PublicKey)},
+			wantErr: ErrNoSignatures,
+		},
+		{
+			name:    "NoKey",
+			sigs:    []cosignoci.Signatures{signedSignatures(t, key, imgDigest)},
+			wantErr: errVerifyNoKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := corpus.SIF(t, "hello-world-docker-v2-manifest", ocisif.OptWriteWithSpareDescriptorCapacity(8))
+
+			s, err := SIFFromPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, sigs := range tt.sigs {
+				if err := s.(CosignWriter).WriteSignatures(t.Context(), imgDigest, sigs); err != nil {
+					t.Fatalf("WriteSignatures() error = %v", err)
+				}
+			}
+
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			svs, err := VerifySignatures(t.Context(), d.(SignedDescriptor), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifySignatures() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got, want := len(svs), tt.wantResults; got != want {
+				t.Errorf("got %v results, want %v", got, want)
+			}
+
+			verified := 0
+			for _, sv := range svs {
+				if got, want := sv.Digest, imgDigest; got != want {
+					t.Errorf("got digest %v, want %v", got, want)
+				}
+				if sv.Err == nil {
+					verified++
+				}
+			}
+			if got, want := verified, tt.wantVerified; got != want {
+				t.Errorf("got %v verified signatures, want %v", got, want)
+			}
+		})
+	}
+}
+
+// testCert returns a certificate created from tmpl for pub, signed by parent
+// with parentKey. If parent is nil, the certificate is self-signed.
+func testCert(
+	t *testing.T, tmpl, parent *x509.Certificate, pub crypto.PublicKey, parentKey crypto.Signer,
+) *x509.Certificate {
+	t.Helper()
+
+	if parent == nil {
+		parent = tmpl
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// testCA returns a root certificate, and an intermediate certificate and key
+// issued by it, valid from notBefore to notAfter.
+func testCA(t *testing.T, notBefore, notAfter time.Time) (*x509.Certificate, *x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := testCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, &rootKey.PublicKey, rootKey)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intermediate := testCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, root, &key.PublicKey, rootKey)
+
+	return root, intermediate, key
+}
+
+// certSignatures returns cosign signatures holding a single simple signing
+// payload for digest h, signed with key. The signing certificate cert, and the
+// chain of certificates that issued it, are carried by the signature. If
+// tsaChain is not empty, an RFC3161 timestamp of the signature, issued at time
+// ts by tsaChain[0] with tsaKey, is also carried by the signature. The
+// certificates that issued tsaChain[0] are the remaining entries of tsaChain.
+func certSignatures(
+	t *testing.T,
+	key *ecdsa.PrivateKey,
+	h v1.Hash,
+	cert *x509.Certificate,
+	chain, tsaChain []*x509.Certificate,
+	tsaKey crypto.Signer,
+	ts time.Time,
+) cosignoci.Signatures {
+	t.Helper()
+
+	payload := fmt.Sprintf(`{"critical":{"identity":{"docker-reference":""},`+
+		`"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, h)
+
+	sum := sha256.Sum256([]byte(payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var chainPEM []byte
+	for _, c := range chain {
+		chainPEM = append(chainPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+
+	annotations := map[string]string{
+		cosignstatic.CertificateAnnotationKey: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+		cosignstatic.ChainAnnotationKey:       string(chainPEM),
+	}
+
+	if len(tsaChain) > 0 {
+		sigSum := sha256.Sum256(sig)
+		tsr, err := (&timestamp.Timestamp{
+			HashAlgorithm:     crypto.SHA256,
+			HashedMessage:     sigSum[:],
+			Time:              ts,
+			Policy:            asn1.ObjectIdentifier{1, 2, 3, 4},
+			Certificates:      tsaChain[1:],
+			AddTSACertificate: true,
+		}).CreateResponseWithOpts(tsaChain[0], tsaKey, crypto.SHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := json.Marshal(bundle.RFC3161Timestamp{SignedRFC3161Timestamp: tsr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		annotations[cosignstatic.RFC3161TimestampAnnotationKey] = string(b)
+	}
+
+	return annotatedSignatures(t, payload, base64.StdEncoding.EncodeToString(sig), annotations)
+}
+
+func TestVerifySignaturesCertificates(t *testing.T) {
+	imgDigest := v1.Hash{Algorithm: "sha256", Hex: "432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338"}
+
+	now := time.Now()
+
+	root, intermediate, caKey := testCA(t, now.Add(-24*time.Hour), now.Add(24*time.Hour))
+	otherRoot, _, _ := testCA(t, now.Add(-24*time.Hour), now.Add(24*time.Hour))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingCert := func(notBefore, notAfter time.Time) *x509.Certificate {
+		return testCert(t, &x509.Certificate{
+			SerialNumber:   big.NewInt(3),
+			NotBefore:      notBefore,
+			NotAfter:       notAfter,
+			KeyUsage:       x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+			EmailAddresses: []string{"signer@example.com"},
+		}, intermediate, &key.PublicKey, caKey)
+	}
+	validCert := signingCert(now.Add(-time.Hour), now.Add(time.Hour))
+	expiredCert := signingCert(now.Add(-2*time.Hour), now.Add(-time.Hour))
+
+	// RFC3161 requires the timestamping extended key usage to be marked critical.
+	ekuOID := asn1.ObjectIdentifier{2, 5, 29, 37}
+	eku, err := asn1.Marshal([]asn1.ObjectIdentifier{{1, 3, 6, 1, 5, 5, 7, 3, 8}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tsaRoot, tsaIntermediate, tsaCAKey := testCA(t, now.Add(-24*time.Hour), now.Add(24*time.Hour))
+	tsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tsaCert := testCert(t, &x509.Certificate{
+		SerialNumber:    big.NewInt(4),
+		Subject:         pkix.Name{CommonName: "tsa"},
+		NotBefore:       now.Add(-24 * time.Hour),
+		NotAfter:        now.Add(24 * time.Hour),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{{Id: ekuOID, Critical: true, Value: eku}},
+	}, tsaIntermediate, &tsaKey.PublicKey, tsaCAKey)
+
+	chain := []*x509.Certificate{intermediate, root}
+	tsaChain := []*x509.Certificate{tsaCert, tsaIntermediate}
+	whileValid := now.Add(-90 * time.Minute)
+
+	tests := []struct {
+		name         string
+		sigs         cosignoci.Signatures
+		opts         []VerifyOpt
+		wantVerified int
+	}{
+		{
+			name: "Certificate",
+			sigs: certSignatures(t, key, imgDigest, validCert, chain, nil, nil, time.Time{}),
+			opts: []VerifyOpt{
+				VerifyWithCertificates([]*x509.Certificate{root}, []*x509.Certificate{intermediate}),
+			},
+			wantVerified: 1,
+		},
+		{
+			name: "CertificateChainAnnotation",
+			sigs: certSignatures(t, key, imgDigest, validCert, chain, nil, nil, time.Time{}),
+			opts: []VerifyOpt{
+				VerifyWithCertificates([]*x509.Certificate{root}, nil),
+			},
+			wantVerified: 1,
+		},
+		{
+			name: "CertificateUntrusted",
+			sigs: certSignatures(t, key, imgDigest, validCert, chain, nil, nil, time.Time{}),
+			opts: []VerifyOpt{
+				VerifyWithCertificates([]*x509.Certificate{otherRoot}, nil),
+			},
+		},
+		{
+			name: "CertificateIdentity",
+			sigs: certSignatures(t, key, imgDigest, validCert, chain, nil, nil, time.Time{}),
+			opts: []VerifyOpt{
+				VerifyWithCertificates([]*x509.Certificate{root}, []*x509.Certificate{intermediate}),
+				VerifyWithIdentities(cosign.Identity{Subject: "signer@example.com"}),
+			},
+			wantVerified: 1,
+		},
+		{
+			name: "CertificateIdentityMismatch",
+			sigs: certSignatures(t, key, imgDigest, validCert, chain, nil, nil, time.Time{}),
+			opts: []VerifyOpt{
+				VerifyWithCertificates([]*x509.Certificate{root}, []*x509.Certificate{intermediate}),
+				VerifyWithIdentities(cosign.Identity{Subject: "other@example.com"}),
+			},
+		},
+		{
+			name: "CertificateExpired",
+			sigs: certSignatures(t, key, imgDigest, expiredCert, chain, nil, nil, time.Time{}),
+			opts: []VerifyOpt{
+				VerifyWithCertificates([]*x509.Certificate{root}, []*x509.Certificate{intermediate}),
+			},
+		},
+		{
+			name: "CertificateExpiredTimestamp",
+			sigs: certSignatures(t, key, imgDigest, expiredCert, chain, tsaChain, tsaKey, whileValid),
+			opts: []VerifyOpt{
+				VerifyWithCertificates([]*x509.Certificate{root}, []*x509.Certificate{intermediate}),
+				VerifyWithTSACertificates([]*x509.Certificate{tsaRoot}, []*x509.Certificate{tsaIntermediate}),
+			},
+			wantVerified: 1,
+		},
+		{
+			name: "CertificateExpiredTimestampNotTrusted",
+			sigs: certSignatures(t, key, imgDigest, expiredCert, chain, tsaChain, tsaKey, whileValid),
+			opts: []VerifyOpt{
+				VerifyWithCertificates([]*x509.Certificate{root}, []*x509.Certificate{intermediate}),
+			},
+		},
+		{
+			name: "TimestampUntrusted",
+			sigs: certSignatures(t, key, imgDigest, validCert, chain, tsaChain, tsaKey, now),
+			opts: []VerifyOpt{
+				VerifyWithCertificates([]*x509.Certificate{root}, []*x509.Certificate{intermediate}),
+				VerifyWithTSACertificates([]*x509.Certificate{otherRoot}, nil),
+			},
+		},
+		{
+			name: "TimestampOutsideValidity",
+			sigs: certSignatures(t, key, imgDigest, expiredCert, chain, tsaChain, tsaKey, now),
+			opts: []VerifyOpt{
+				VerifyWithCertificates([]*x509.Certificate{root}, []*x509.Certificate{intermediate}),
+				VerifyWithTSACertificates([]*x509.Certificate{tsaRoot}, []*x509.Certificate{tsaIntermediate}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := corpus.SIF(t, "hello-world-docker-v2-manifest", ocisif.OptWriteWithSpareDescriptorCapacity(8))
+
+			s, err := SIFFromPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.(CosignWriter).WriteSignatures(t.Context(), imgDigest, tt.sigs); err != nil {
+				t.Fatalf("WriteSignatures() error = %v", err)
+			}
+
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			svs, err := VerifySignatures(t.Context(), d.(SignedDescriptor), tt.opts...)
+			if err != nil {
+				t.Fatalf("VerifySignatures() error = %v", err)
+			}
+
+			if got, want := len(svs), 1; got != want {
+				t.Fatalf("got %v results, want %v", got, want)
+			}
+
+			verified := 0
+			for _, sv := range svs {
+				if sv.Err == nil {
+					verified++
+				}
+			}
+			if got, want := verified, tt.wantVerified; got != want {
+				t.Errorf("got %v verified signatures, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sylabs/oci-tools/pkg/instrumented"
+	"github.com/sylabs/oci-tools/pkg/ociplatform"
+)
+
+// ociSourceSink is used to retrieve/write images and indexes from/to an OCI
+// image layout directory.
+type ociSourceSink struct {
+	path layout.Path
+	opts options
+}
+
+var _ SourceSink = &ociSourceSink{}
+
+func handleOptionsOCI(opts ...Option) (*ociSourceSink, error) {
+	ss := ociSourceSink{
+		opts: options{},
+	}
+	for _, opt := range opts {
+		if err := opt(&ss.opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ss, nil
+}
+
+// OCIFromPath returns an ociSourceSink backed by an existing OCI image layout
+// directory at src.
+func OCIFromPath(src string, opts ...Option) (SourceSink, error) {
+	s, err := handleOptionsOCI(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.path, err = layout.FromPath(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// OCIEmpty will create a new, empty OCI image layout at dst, and return an
+// ociSourceSink that can be used to write/read to/from it.
+func OCIEmpty(dst string, opts ...Option) (SourceSink, error) {
+	s, err := handleOptionsOCI(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.path, err = layout.Write(dst, empty.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+var _ Descriptor = &ociDescriptor{}
+
+// ociDescriptor wraps a v1.Descriptor, providing methods to access the image or
+// index to which it pertains, and the associated manifest, from an underlying
+// OCI image layout.
+type ociDescriptor struct {
+	descriptor v1.Descriptor
+	Manifest   []byte
+
+	path layout.Path
+
+	instrumentationLogger *slog.Logger
+}
+
+// RawManifest returns the manifest of the image or index described by this
+// descriptor.
+func (d *ociDescriptor) RawManifest() ([]byte, error) {
+	return d.Manifest, nil
+}
+
+// MediaType returns the types.MediaType of this descriptor.
+func (d *ociDescriptor) MediaType() types.MediaType {
+	return d.descriptor.MediaType
+}
+
+// Image returns a v1.Image directly if the descriptor is associated with an
+// OCI image, or an image for the local platform if the descriptor is
+// associated with an OCI ImageIndex.
+func (d *ociDescriptor) Image() (v1.Image, error) {
+	switch {
+	case d.descriptor.MediaType.IsImage():
+		// Images are read directly from the blob store, so there is no need to
+		// traverse any parent index.
+		img, err := d.path.Image(d.descriptor.Digest)
+		if err != nil {
+			return nil, err
+		}
+		if d.instrumentationLogger != nil {
+			return instrumented.Image(img, d.instrumentationLogger)
+		}
+		return img, nil
+
+	case d.descriptor.MediaType.IsIndex():
+		ii, err := ociIndex(d.path, d.descriptor.Digest)
+		if err != nil {
+			return nil, err
+		}
+		p := ociplatform.DefaultPlatform()
+		ims, err := partial.FindImages(ii, ociplatform.Matcher(p))
+		if err != nil {
+			return nil, err
+		}
+		if n := len(ims); n == 0 {
+			return nil, ErrNoManifest
+		} else if n > 1 {
+			return nil, ErrMultipleManifests
+		}
+		if d.instrumentationLogger != nil {
+			return instrumented.Image(ims[0], d.instrumentationLogger)
+		}
+		return ims[0], nil
+
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+}
+
+// ImageIndex returns a v1.ImageIndex if the descriptor is associated with
+// an OCI ImageIndex.
+func (d *ociDescriptor) ImageIndex() (v1.ImageIndex, error) {
+	if !d.descriptor.MediaType.IsIndex() {
+		return nil, ErrUnsupportedMediaType
+	}
+	ii, err := ociIndex(d.path, d.descriptor.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if d.instrumentationLogger != nil {
+		return instrumented.Index(ii, d.instrumentationLogger)
+	}
+	return ii, nil
+}
+
+// ociIndex returns the index with digest h, which may be referenced from the
+// index.json of the layout at p directly, or from a child index.
+func ociIndex(p layout.Path, h v1.Hash) (v1.ImageIndex, error) {
+	ri, err := p.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	return findIndex(ri, h)
+}
+
+// findIndex returns the index with digest h, which may be referenced from ri
+// directly, or from a child index.
+func findIndex(ri v1.ImageIndex, h v1.Hash) (v1.ImageIndex, error) {
+	iis, err := partial.FindIndexes(ri, match.Digests(h))
+	if err != nil {
+		return nil, err
+	}
+	if len(iis) == 0 {
+		return nil, ErrNoManifest
+	}
+	return iis[0], nil
+}
+
+// Get will find an image or index in the OCI layout that matches the
+// requirements specified by opts. If GetWithPlatform is specified then the
+// Descriptor returned will always be an image that satisfies the platform.
+// Otherwise, the Descriptor returned can be an image or an index.
+func (o *ociSourceSink) Get(_ context.Context, opts ...GetOpt) (Descriptor, error) {
+	gOpts := getOpts{}
+	for _, opt := range opts {
+		if err := opt(&gOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	ri, err := o.path.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := partial.FindManifests(ri, getMatcher(gOpts))
+	if err != nil {
+		return nil, err
+	}
+	if len(ds) == 0 {
+		return nil, ErrNoManifest
+	}
+	if len(ds) > 1 {
+		return nil, ErrMultipleManifests
+	}
+
+	mt := ds[0].MediaType
+	switch {
+	case mt.IsImage():
+		img, err := ri.Image(ds[0].Digest)
+		if err != nil {
+			return nil, err
+		}
+		if gOpts.platform != nil {
+			if err := ociplatform.EnsureImageSatisfies(img, *gOpts.platform); err != nil {
+				return nil, err
+			}
+		}
+		mf, err := img.RawManifest()
+		if err != nil {
+			return nil, err
+		}
+		return &ociDescriptor{
+			descriptor:            ds[0],
+			Manifest:              mf,
+			path:                  o.path,
+			instrumentationLogger: o.opts.instrumentationLogger,
+		}, nil
+	case mt.IsIndex():
+		ii, err := ri.ImageIndex(ds[0].Digest)
+		if err != nil {
+			return nil, err
+		}
+		// Platform wasn't requested - return the index itself.
+		if gOpts.platform == nil {
+			mf, err := ii.RawManifest()
+			if err != nil {
+				return nil, err
+			}
+			return &ociDescriptor{
+				descriptor:            ds[0],
+				Manifest:              mf,
+				path:                  o.path,
+				instrumentationLogger: o.opts.instrumentationLogger,
+			}, nil
+		}
+		// Platform was requested - find an image in the index.
+		return o.imageFromIndex(ii, gOpts.platform)
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedMediaType, mt)
+	}
+}
+
+func (o *ociSourceSink) imageFromIndex(ii v1.ImageIndex, p *v1.Platform) (Descriptor, error) {
+	ims, err := partial.FindImages(ii, ociplatform.Matcher(p))
+	if err != nil {
+		return nil, err
+	}
+	if n := len(ims); n == 0 {
+		return nil, ErrNoManifest
+	} else if n > 1 {
+		return nil, ErrMultipleManifests
+	}
+	d, err := partial.Descriptor(ims[0])
+	if err != nil {
+		return nil, err
+	}
+	mf, err := ims[0].RawManifest()
+	if err != nil {
+		return nil, err
+	}
+	return &ociDescriptor{
+		descriptor:            *d,
+		Manifest:              mf,
+		path:                  o.path,
+		instrumentationLogger: o.opts.instrumentationLogger,
+	}, nil
+}
+
+// Write will write an image or index w to the OCI layout associated with the
+// ociSourceSink, and reference it from the layout's index.json.
+//
+// If WriteWithReference is specified, the reference is set as an
+// `org.opencontainers.image.ref.name` annotation on the new descriptor, and is
+// removed from any existing descriptors in index.json.
+func (o *ociSourceSink) Write(_ context.Context, w Writable, opts ...WriteOpt) error {
+	wOpts := writeOpts{}
+	for _, opt := range opts {
+		if err := opt(&wOpts); err != nil {
+			return err
+		}
+	}
+
+	var desc *v1.Descriptor
+	switch w := w.(type) {
+	case v1.Image:
+		if err := o.path.WriteImage(w); err != nil {
+			return err
+		}
+		d, err := partial.Descriptor(w)
+		if err != nil {
+			return err
+		}
+		desc = d
+	case v1.ImageIndex:
+		if err := o.path.WriteIndex(w); err != nil {
+			return err
+		}
+		d, err := partial.Descriptor(w)
+		if err != nil {
+			return err
+		}
+		desc = d
+	default:
+		return ErrUnsupportedMediaType
+	}
+
+	ri, err := o.path.ImageIndex()
+	if err != nil {
+		return err
+	}
+	im, err := ri.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	return writeOCIIndexJSON(o.path, appendDescriptor(im, *desc, wOpts.reference))
+}
+
+// appendDescriptor returns a copy of im, with desc appended to its manifests. If
+// ref is not nil, it is set as an `org.opencontainers.image.ref.name`
+// annotation on desc, and removed from any existing descriptors.
+func appendDescriptor(im *v1.IndexManifest, desc v1.Descriptor, ref name.Reference) *v1.IndexManifest {
+	im = im.DeepCopy()
+
+	if ref != nil {
+		// Remove the reference from any existing descriptors.
+		for i, d := range im.Manifests {
+			if d.Annotations[imagespec.AnnotationRefName] == ref.Name() {
+				delete(im.Manifests[i].Annotations, imagespec.AnnotationRefName)
+			}
+		}
+
+		if desc.Annotations != nil {
+			desc.Annotations = maps.Clone(desc.Annotations)
+		} else {
+			desc.Annotations = make(map[string]string)
+		}
+		desc.Annotations[imagespec.AnnotationRefName] = ref.Name()
+	}
+
+	im.Manifests = append(im.Manifests, desc)
+
+	return im
+}
+
+// writeOCIIndexJSON replaces the index.json of the OCI layout at p with im.
+func writeOCIIndexJSON(p layout.Path, im *v1.IndexManifest) error {
+	b, err := json.MarshalIndent(im, "", "   ")
+	if err != nil {
+		return err
+	}
+	return p.WriteFile("index.json", b, os.ModePerm)
+}
+
+// Blob returns an io.Readcloser for the content of the blob with a digest
+// specified using the GetWithDigest option.
+func (o *ociSourceSink) Blob(_ context.Context, opts ...GetOpt) (io.ReadCloser, error) {
+	h, err := blobDigest(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.path.Blob(h)
+}
@@ -0,0 +1,373 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/sebdah/goldie/v2"
+	"github.com/sylabs/oci-tools/pkg/ociplatform"
+)
+
+func TestOCIFromPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		opts    []Option
+		wantErr bool
+	}{
+		{
+			name: "Defaults",
+			src:  corpus.ImagePath("hello-world-docker-v2-manifest"),
+		},
+		{
+			name: "WithInstrumentationLogs",
+			src:  corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts: []Option{OptWithInstrumentationLogs(slog.Default())},
+		},
+		{
+			name:    "NotLayout",
+			src:     t.TempDir(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OCIFromPath(tt.src, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OCIFromPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOCIGet(t *testing.T) {
+	imgDigest := v1.Hash{Algorithm: "sha256", Hex: "432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338"}
+	idxDigest := v1.Hash{Algorithm: "sha256", Hex: "00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed"}
+	sigRef := name.MustParseReference(
+		"_cosign:sha256-432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338.sig",
+		name.WithDefaultRegistry(""),
+	)
+
+	tests := []struct {
+		name    string
+		src     string
+		opts    []GetOpt
+		wantErr error
+	}{
+		{
+			name:    "ImageDefaults",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts:    []GetOpt{},
+			wantErr: nil,
+		},
+		{
+			name:    "ImagePlatform",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "arm64"})},
+			wantErr: nil,
+		},
+		{
+			name:    "ImageBadPlatform",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "m68k"})},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "ImageDigest",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts:    []GetOpt{GetWithDigest(imgDigest)},
+			wantErr: nil,
+		},
+		{
+			name:    "ImageBadDigest",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts:    []GetOpt{GetWithDigest(v1.Hash{})},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "ImageReference",
+			src:     corpus.ImagePath("hello-world-cosign-manifest"),
+			opts:    []GetOpt{GetWithReference(sigRef)},
+			wantErr: nil,
+		},
+		{
+			name:    "ImageBadReference",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts:    []GetOpt{GetWithReference(sigRef)},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "IndexDefaults",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest-list"),
+			opts:    []GetOpt{},
+			wantErr: nil,
+		},
+		{
+			name:    "IndexPlatform",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest-list"),
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "arm64", Variant: "v8"})},
+			wantErr: nil,
+		},
+		{
+			name:    "IndexBadPlatform",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest-list"),
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "m68k"})},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "IndexDigest",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest-list"),
+			opts:    []GetOpt{GetWithDigest(idxDigest)},
+			wantErr: nil,
+		},
+		{
+			name:    "IndexBadDigest",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest-list"),
+			opts:    []GetOpt{GetWithDigest(v1.Hash{})},
+			wantErr: ErrNoManifest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := OCIFromPath(tt.src)
+			if err != nil {
+				t.Fatalf("OCIFromPath() error = %v", err)
+			}
+			d, err := s.Get(t.Context(), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			mf, err := d.RawManifest()
+			if err != nil {
+				t.Fatalf("RawManifest() error = %v", err)
+			}
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, mf)
+		})
+	}
+}
+
+func TestOCIDescriptorImage(t *testing.T) {
+	tests := []struct {
+		name         string
+		src          string
+		wantPlatform v1.Platform
+	}{
+		{
+			name:         "FromImage",
+			src:          corpus.ImagePath("hello-world-docker-v2-manifest"),
+			wantPlatform: v1.Platform{OS: "Linux", Architecture: "arm64", Variant: "v8"},
+		},
+		{
+			name:         "FromIndex",
+			src:          corpus.ImagePath("hello-world-docker-v2-manifest-list"),
+			wantPlatform: *ociplatform.DefaultPlatform(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := OCIFromPath(tt.src)
+			if err != nil {
+				t.Fatalf("OCIFromPath() error = %v", err)
+			}
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatalf(".Get() error = %v", err)
+			}
+
+			img, err := d.Image()
+			if err != nil {
+				t.Fatalf(".Image() error = %v", err)
+			}
+
+			if err := ociplatform.EnsureImageSatisfies(img, tt.wantPlatform); err != nil {
+				t.Fatalf("Image does not satisfy expected platform %v", tt.wantPlatform)
+			}
+		})
+	}
+}
+
+func TestOCIDescriptorImageIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr error
+	}{
+		{
+			name:    "FromImage",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest"),
+			wantErr: ErrUnsupportedMediaType,
+		},
+		{
+			name:    "FromIndex",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest-list"),
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := OCIFromPath(tt.src)
+			if err != nil {
+				t.Fatalf("OCIFromPath() error = %v", err)
+			}
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatalf(".Get() error = %v", err)
+			}
+
+			_, err = d.ImageIndex()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf(".ImageIndex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOCIWrite(t *testing.T) {
+	ref := name.MustParseReference("my:image", name.WithDefaultRegistry(""))
+
+	tests := []struct {
+		name   string
+		writes []Writable
+		opts   []WriteOpt
+	}{
+		{
+			name:   "ImageDefaults",
+			writes: []Writable{corpus.Image(t, "hello-world-docker-v2-manifest")},
+			opts:   []WriteOpt{},
+		},
+		{
+			name:   "ImageWithReference",
+			writes: []Writable{corpus.Image(t, "hello-world-docker-v2-manifest")},
+			opts:   []WriteOpt{WriteWithReference(ref)},
+		},
+		{
+			name:   "IndexDefaults",
+			writes: []Writable{corpus.ImageIndex(t, "hello-world-docker-v2-manifest-list")},
+			opts:   []WriteOpt{},
+		},
+		{
+			name:   "IndexWithReference",
+			writes: []Writable{corpus.ImageIndex(t, "hello-world-docker-v2-manifest-list")},
+			opts:   []WriteOpt{WriteWithReference(ref)},
+		},
+		{
+			name: "MoveReference",
+			writes: []Writable{
+				corpus.Image(t, "hello-world-docker-v2-manifest"),
+				corpus.ImageIndex(t, "hello-world-docker-v2-manifest-list"),
+			},
+			opts: []WriteOpt{WriteWithReference(ref)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "layout")
+
+			s, err := OCIEmpty(path)
+			if err != nil {
+				t.Fatalf("OCIEmpty() error = %v", err)
+			}
+			for _, w := range tt.writes {
+				if err := s.Write(t.Context(), w, tt.opts...); err != nil {
+					t.Fatalf(".Write() error = %v", err)
+				}
+			}
+
+			index, err := os.ReadFile(filepath.Join(path, "index.json"))
+			if err != nil {
+				t.Fatalf("while reading index.json: %v", err)
+			}
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, index)
+		})
+	}
+}
+
+func TestOCIBlob(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		opts    []GetOpt
+		wantErr error
+	}{
+		{
+			name: "ImageConfig",
+			src:  corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "46331d942d6350436f64e614d75725f6de3bb5c63e266e236e04389820a234c4"})},
+			wantErr: nil,
+		},
+		{
+			name: "ImageLayer",
+			src:  corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff915842962cb813f32062d3bbdd35c750dd7d01"})},
+			wantErr: nil,
+		},
+		{
+			name:    "ErrNoDigest",
+			src:     corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts:    []GetOpt{},
+			wantErr: errBlobNoDigest,
+		},
+		{
+			name: "ErrPlatform",
+			src:  corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts: []GetOpt{
+				GetWithDigest(v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff95842962cb813f32062d3bbdd35c750dd7d01"}),
+				GetWithPlatform(*ociplatform.DefaultPlatform()),
+			},
+			wantErr: errBlobPlatform,
+		},
+		{
+			name: "ErrReference",
+			src:  corpus.ImagePath("hello-world-docker-v2-manifest"),
+			opts: []GetOpt{
+				GetWithDigest(v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff95842962cb813f32062d3bbdd35c750dd7d01"}),
+				GetWithReference(name.MustParseReference("test")),
+			},
+			wantErr: errBlobReference,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := OCIFromPath(tt.src)
+			if err != nil {
+				t.Fatalf("OCIFromPath() error = %v", err)
+			}
+			rc, err := s.Blob(t.Context(), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Blob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+			defer rc.Close()
+
+			b, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("ReadAll error: %v", err)
+			}
+
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, b)
+		})
+	}
+}
@@ -0,0 +1,378 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/sylabs/oci-tools/pkg/instrumented"
+	"github.com/sylabs/oci-tools/pkg/ociplatform"
+)
+
+// registrySourceSink is used to retrieve/write images and indexes from/to an
+// OCI distribution registry.
+type registrySourceSink struct {
+	ref  name.Reference
+	opts options
+}
+
+var _ SourceSink = &registrySourceSink{}
+
+var errInsecureTransport = errors.New("insecure access requires a transport of type *http.Transport")
+
+func handleOptionsRegistry(opts ...Option) (*registrySourceSink, error) {
+	ss := registrySourceSink{
+		opts: options{},
+	}
+	for _, opt := range opts {
+		if err := opt(&ss.opts); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply the insecure TLS setting to a supplied transport, which would
+	// otherwise be used as-is.
+	if rt := ss.opts.transport; rt != nil && ss.opts.insecure {
+		t, ok := rt.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("%w: got %T", errInsecureTransport, rt)
+		}
+		ss.opts.transport = insecureTransport(t)
+	}
+
+	return &ss, nil
+}
+
+// insecureTransport returns a copy of t that does not verify TLS certificates.
+func insecureTransport(t *http.Transport) *http.Transport {
+	t = t.Clone()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{} //nolint:gosec
+	}
+	t.TLSClientConfig.InsecureSkipVerify = true
+	return t
+}
+
+// RegistryFromReference returns a registrySourceSink that reads / writes images
+// and indexes from / to an OCI distribution registry. By default, Get and Write
+// operate on ref. Other references can be selected with GetWithReference and
+// WriteWithReference.
+//
+// Credentials are obtained from the default keychain, unless
+// OptWithKeychain is specified. To access a registry over plain HTTP, consider
+// using OptWithInsecure.
+func RegistryFromReference(ref name.Reference, opts ...Option) (SourceSink, error) {
+	s, err := handleOptionsRegistry(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ref, err = s.reference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// reference returns ref, parsed according to the options of the
+// registrySourceSink. A reference in the '_cosign' placeholder repository is
+// resolved against the repository of the registrySourceSink.
+func (o *registrySourceSink) reference(ref name.Reference) (name.Reference, error) {
+	if o.ref != nil && ref.Context().RegistryStr() == "" && ref.Context().RepositoryStr() == CosignPlaceholderRepo {
+		return o.ref.Context().Tag(ref.Identifier()), nil
+	}
+	if !o.opts.insecure {
+		return ref, nil
+	}
+	return name.ParseReference(ref.String(), name.Insecure)
+}
+
+// remoteOptions returns the remote.Options that should be used for an
+// operation against the registry.
+func (o *registrySourceSink) remoteOptions(ctx context.Context) []remote.Option {
+	return registryRemoteOptions(ctx, o.opts)
+}
+
+// registryRemoteOptions returns the remote.Options corresponding to opts.
+func registryRemoteOptions(ctx context.Context, opts options) []remote.Option {
+	kc := opts.keychain
+	if kc == nil {
+		kc = authn.DefaultKeychain
+	}
+
+	ropts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(kc),
+	}
+
+	if t := opts.transport; t != nil {
+		ropts = append(ropts, remote.WithTransport(t))
+	} else if t, ok := remote.DefaultTransport.(*http.Transport); ok && opts.insecure {
+		ropts = append(ropts, remote.WithTransport(insecureTransport(t)))
+	}
+
+	return ropts
+}
+
+// registryErr converts errors indicating that a manifest was not found at the
+// registry into ErrNoManifest.
+func registryErr(err error) error {
+	var terr *transport.Error
+	if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w", ErrNoManifest, err)
+	}
+	return err
+}
+
+var _ Descriptor = &registryDescriptor{}
+
+// registryDescriptor wraps a v1.Descriptor, providing methods to access the
+// image or index to which it pertains, and the associated manifest, from an
+// OCI distribution registry.
+type registryDescriptor struct {
+	descriptor v1.Descriptor
+	Manifest   []byte
+
+	ref  name.Digest
+	opts options
+
+	// ctx is the context passed to Get, which is used when the image or index
+	// is retrieved.
+	ctx context.Context
+
+	instrumentationLogger *slog.Logger
+}
+
+// RawManifest returns the manifest of the image or index described by this
+// descriptor.
+func (d *registryDescriptor) RawManifest() ([]byte, error) {
+	return d.Manifest, nil
+}
+
+// MediaType returns the types.MediaType of this descriptor.
+func (d *registryDescriptor) MediaType() types.MediaType {
+	return d.descriptor.MediaType
+}
+
+// Image returns a v1.Image directly if the descriptor is associated with an
+// OCI image, or an image for the local platform if the descriptor is
+// associated with an OCI ImageIndex.
+func (d *registryDescriptor) Image() (v1.Image, error) {
+	ropts := registryRemoteOptions(d.ctx, d.opts)
+
+	switch {
+	case d.descriptor.MediaType.IsImage():
+		img, err := remote.Image(d.ref, ropts...)
+		if err != nil {
+			return nil, registryErr(err)
+		}
+		if d.instrumentationLogger != nil {
+			return instrumented.Image(img, d.instrumentationLogger)
+		}
+		return img, nil
+
+	case d.descriptor.MediaType.IsIndex():
+		ii, err := remote.Index(d.ref, ropts...)
+		if err != nil {
+			return nil, registryErr(err)
+		}
+		p := ociplatform.DefaultPlatform()
+		ims, err := partial.FindImages(ii, ociplatform.Matcher(p))
+		if err != nil {
+			return nil, err
+		}
+		if n := len(ims); n == 0 {
+			return nil, ErrNoManifest
+		} else if n > 1 {
+			return nil, ErrMultipleManifests
+		}
+		if d.instrumentationLogger != nil {
+			return instrumented.Image(ims[0], d.instrumentationLogger)
+		}
+		return ims[0], nil
+
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+}
+
+// ImageIndex returns a v1.ImageIndex if the descriptor is associated with
+// an OCI ImageIndex.
+func (d *registryDescriptor) ImageIndex() (v1.ImageIndex, error) {
+	if !d.descriptor.MediaType.IsIndex() {
+		return nil, ErrUnsupportedMediaType
+	}
+	ii, err := remote.Index(d.ref, registryRemoteOptions(d.ctx, d.opts)...)
+	if err != nil {
+		return nil, registryErr(err)
+	}
+	if d.instrumentationLogger != nil {
+		return instrumented.Index(ii, d.instrumentationLogger)
+	}
+	return ii, nil
+}
+
+// Get will find an image or index in the registry that matches the
+// requirements specified by opts. If GetWithReference is not specified, the
+// reference provided when the registrySourceSink was created is used. If
+// GetWithDigest is specified, the manifest with that digest is retrieved from
+// the repository of the reference.
+//
+// If GetWithPlatform is specified then the Descriptor returned will always be
+// an image that satisfies the platform. Otherwise, the Descriptor returned can
+// be an image or an index.
+func (o *registrySourceSink) Get(ctx context.Context, opts ...GetOpt) (Descriptor, error) {
+	gOpts := getOpts{}
+	for _, opt := range opts {
+		if err := opt(&gOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	ref := o.ref
+	if gOpts.reference != nil {
+		var err error
+		if ref, err = o.reference(gOpts.reference); err != nil {
+			return nil, err
+		}
+	}
+	if gOpts.digest != nil {
+		ref = ref.Context().Digest(gOpts.digest.String())
+	}
+
+	ropts := o.remoteOptions(ctx)
+
+	rd, err := remote.Get(ref, ropts...)
+	if err != nil {
+		return nil, registryErr(err)
+	}
+
+	mt := rd.MediaType
+	switch {
+	case mt.IsImage():
+		if gOpts.platform != nil {
+			img, err := rd.Image()
+			if err != nil {
+				return nil, err
+			}
+			if err := ociplatform.EnsureImageSatisfies(img, *gOpts.platform); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrNoManifest, err)
+			}
+		}
+		return o.descriptor(ctx, ref.Context(), rd.Descriptor, rd.Manifest), nil
+	case mt.IsIndex():
+		// Platform wasn't requested - return the index itself.
+		if gOpts.platform == nil {
+			return o.descriptor(ctx, ref.Context(), rd.Descriptor, rd.Manifest), nil
+		}
+		// Platform was requested - find an image in the index.
+		ii, err := rd.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+		return o.imageFromIndex(ctx, ref.Context(), ii, gOpts.platform)
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedMediaType, mt)
+	}
+}
+
+// descriptor returns a registryDescriptor for the manifest with descriptor desc
+// in repo. The image or index is retrieved using ctx.
+func (o *registrySourceSink) descriptor(
+	ctx context.Context, repo name.Repository, desc v1.Descriptor, mf []byte,
+) *registryDescriptor {
+	return &registryDescriptor{
+		descriptor:            desc,
+		Manifest:              mf,
+		ref:                   repo.Digest(desc.Digest.String()),
+		opts:                  o.opts,
+		ctx:                   ctx,
+		instrumentationLogger: o.opts.instrumentationLogger,
+	}
+}
+
+func (o *registrySourceSink) imageFromIndex(
+	ctx context.Context, repo name.Repository, ii v1.ImageIndex, p *v1.Platform,
+) (Descriptor, error) {
+	ims, err := partial.FindImages(ii, ociplatform.Matcher(p))
+	if err != nil {
+		return nil, err
+	}
+	if n := len(ims); n == 0 {
+		return nil, ErrNoManifest
+	} else if n > 1 {
+		return nil, ErrMultipleManifests
+	}
+	d, err := partial.Descriptor(ims[0])
+	if err != nil {
+		return nil, err
+	}
+	mf, err := ims[0].RawManifest()
+	if err != nil {
+		return nil, err
+	}
+	return o.descriptor(ctx, repo, *d, mf), nil
+}
+
+// Write will push an image or index w to the registry. If WriteWithReference is
+// not specified, the reference provided when the registrySourceSink was created
+// is used.
+func (o *registrySourceSink) Write(ctx context.Context, w Writable, opts ...WriteOpt) error {
+	wOpts := writeOpts{}
+	for _, opt := range opts {
+		if err := opt(&wOpts); err != nil {
+			return err
+		}
+	}
+
+	ref := o.ref
+	if wOpts.reference != nil {
+		var err error
+		if ref, err = o.reference(wOpts.reference); err != nil {
+			return err
+		}
+	}
+
+	if img, ok := w.(v1.Image); ok {
+		return remote.Write(ref, img, o.remoteOptions(ctx)...)
+	}
+
+	if ii, ok := w.(v1.ImageIndex); ok {
+		return remote.WriteIndex(ref, ii, o.remoteOptions(ctx)...)
+	}
+
+	return ErrUnsupportedMediaType
+}
+
+// Blob returns an io.Readcloser for the content of the blob with a digest
+// specified using the GetWithDigest option. The blob is read from the
+// repository of the reference provided when the registrySourceSink was created.
+func (o *registrySourceSink) Blob(ctx context.Context, opts ...GetOpt) (io.ReadCloser, error) {
+	h, err := blobDigest(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := remote.Layer(o.ref.Context().Digest(h.String()), o.remoteOptions(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Compressed()
+}
@@ -0,0 +1,564 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sebdah/goldie/v2"
+	"github.com/sylabs/oci-tools/pkg/ociplatform"
+)
+
+// newTestRegistry starts an in-memory registry, returning its host.
+func newTestRegistry(t *testing.T) string {
+	t.Helper()
+
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(s.Close)
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Host
+}
+
+// pushTestImages pushes the named corpus images to the registry at host,
+// returning a reference to each.
+func pushTestImages(t *testing.T, host string, names ...string) map[string]name.Reference {
+	t.Helper()
+
+	refs := make(map[string]name.Reference)
+	for _, n := range names {
+		ref, err := name.ParseReference(host + "/" + n + ":latest")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ii, err := layout.ImageIndexFromPath(corpus.ImagePath(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		im, err := ii.IndexManifest()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mt := im.Manifests[0].MediaType; mt.IsIndex() {
+			idx, err := ii.ImageIndex(im.Manifests[0].Digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := remote.WriteIndex(ref, idx); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			img, err := ii.Image(im.Manifests[0].Digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := remote.Write(ref, img); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		refs[n] = ref
+	}
+	return refs
+}
+
+// countingTransport counts requests made via an underlying http.RoundTripper.
+type countingTransport struct {
+	rt http.RoundTripper
+	n  atomic.Int64
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.n.Add(1)
+	return t.rt.RoundTrip(r)
+}
+
+func TestRegistryFromReference(t *testing.T) {
+	host := newTestRegistry(t)
+	refs := pushTestImages(t, host, "hello-world-docker-v2-manifest")
+	ref := refs["hello-world-docker-v2-manifest"]
+
+	ct := &countingTransport{rt: http.DefaultTransport}
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		wantErr       error
+		wantTransport bool
+	}{
+		{
+			name: "Defaults",
+		},
+		{
+			name: "WithInstrumentationLogs",
+			opts: []Option{OptWithInstrumentationLogs(slog.Default())},
+		},
+		{
+			name: "WithKeychain",
+			opts: []Option{OptWithKeychain(authn.NewMultiKeychain())},
+		},
+		{
+			name: "WithInsecure",
+			opts: []Option{OptWithInsecure(true)},
+		},
+		{
+			name:          "WithTransport",
+			opts:          []Option{OptWithTransport(ct)},
+			wantTransport: true,
+		},
+		{
+			name: "WithInsecureHTTPTransport",
+			opts: []Option{OptWithTransport(&http.Transport{}), OptWithInsecure(true)},
+		},
+		{
+			name:    "WithInsecureTransport",
+			opts:    []Option{OptWithTransport(ct), OptWithInsecure(true)},
+			wantErr: errInsecureTransport,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := ct.n.Load()
+
+			s, err := RegistryFromReference(ref, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RegistryFromReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if _, err := s.Get(t.Context()); err != nil {
+				t.Fatalf(".Get() error = %v", err)
+			}
+
+			if got := ct.n.Load() > before; got != tt.wantTransport {
+				t.Errorf("transport used = %v, want %v", got, tt.wantTransport)
+			}
+		})
+	}
+}
+
+func TestRegistryGet(t *testing.T) {
+	host := newTestRegistry(t)
+	refs := pushTestImages(t, host,
+		"hello-world-docker-v2-manifest",
+		"hello-world-docker-v2-manifest-list",
+	)
+
+	imgDigest := v1.Hash{Algorithm: "sha256", Hex: "432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338"}
+	idxDigest := v1.Hash{Algorithm: "sha256", Hex: "00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed"}
+	badDigest := v1.Hash{Algorithm: "sha256", Hex: "0000000000000000000000000000000000000000000000000000000000000000"}
+
+	tests := []struct {
+		name    string
+		src     name.Reference
+		opts    []GetOpt
+		wantErr error
+	}{
+		{
+			name:    "ImageDefaults",
+			src:     refs["hello-world-docker-v2-manifest"],
+			opts:    []GetOpt{},
+			wantErr: nil,
+		},
+		{
+			name:    "ImagePlatform",
+			src:     refs["hello-world-docker-v2-manifest"],
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "arm64"})},
+			wantErr: nil,
+		},
+		{
+			name:    "ImageBadPlatform",
+			src:     refs["hello-world-docker-v2-manifest"],
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "m68k"})},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "ImageDigest",
+			src:     refs["hello-world-docker-v2-manifest"],
+			opts:    []GetOpt{GetWithDigest(imgDigest)},
+			wantErr: nil,
+		},
+		{
+			name:    "ImageBadDigest",
+			src:     refs["hello-world-docker-v2-manifest"],
+			opts:    []GetOpt{GetWithDigest(badDigest)},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "ImageReference",
+			src:     refs["hello-world-docker-v2-manifest-list"],
+			opts:    []GetOpt{GetWithReference(refs["hello-world-docker-v2-manifest"])},
+			wantErr: nil,
+		},
+		{
+			name:    "ImageBadReference",
+			src:     refs["hello-world-docker-v2-manifest"],
+			opts:    []GetOpt{GetWithReference(refs["hello-world-docker-v2-manifest"].Context().Tag("missing"))},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "IndexDefaults",
+			src:     refs["hello-world-docker-v2-manifest-list"],
+			opts:    []GetOpt{},
+			wantErr: nil,
+		},
+		{
+			name:    "IndexPlatform",
+			src:     refs["hello-world-docker-v2-manifest-list"],
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "arm64", Variant: "v8"})},
+			wantErr: nil,
+		},
+		{
+			name:    "IndexBadPlatform",
+			src:     refs["hello-world-docker-v2-manifest-list"],
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "m68k"})},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "IndexDigest",
+			src:     refs["hello-world-docker-v2-manifest-list"],
+			opts:    []GetOpt{GetWithDigest(idxDigest)},
+			wantErr: nil,
+		},
+		{
+			name:    "IndexBadDigest",
+			src:     refs["hello-world-docker-v2-manifest-list"],
+			opts:    []GetOpt{GetWithDigest(badDigest)},
+			wantErr: ErrNoManifest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := RegistryFromReference(tt.src)
+			if err != nil {
+				t.Fatalf("RegistryFromReference() error = %v", err)
+			}
+			d, err := s.Get(t.Context(), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			mf, err := d.RawManifest()
+			if err != nil {
+				t.Fatalf("RawManifest() error = %v", err)
+			}
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, mf)
+		})
+	}
+}
+
+func TestRegistryDescriptorImage(t *testing.T) {
+	host := newTestRegistry(t)
+	refs := pushTestImages(t, host,
+		"hello-world-docker-v2-manifest",
+		"hello-world-docker-v2-manifest-list",
+	)
+
+	tests := []struct {
+		name         string
+		src          name.Reference
+		wantPlatform v1.Platform
+	}{
+		{
+			name:         "FromImage",
+			src:          refs["hello-world-docker-v2-manifest"],
+			wantPlatform: v1.Platform{OS: "Linux", Architecture: "arm64", Variant: "v8"},
+		},
+		{
+			name:         "FromIndex",
+			src:          refs["hello-world-docker-v2-manifest-list"],
+			wantPlatform: *ociplatform.DefaultPlatform(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := RegistryFromReference(tt.src)
+			if err != nil {
+				t.Fatalf("RegistryFromReference() error = %v", err)
+			}
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatalf(".Get() error = %v", err)
+			}
+
+			img, err := d.Image()
+			if err != nil {
+				t.Fatalf(".Image() error = %v", err)
+			}
+
+			if err := ociplatform.EnsureImageSatisfies(img, tt.wantPlatform); err != nil {
+				t.Fatalf("Image does not satisfy expected platform %v", tt.wantPlatform)
+			}
+		})
+	}
+}
+
+func TestRegistryDescriptorImageIndex(t *testing.T) {
+	host := newTestRegistry(t)
+	refs := pushTestImages(t, host,
+		"hello-world-docker-v2-manifest",
+		"hello-world-docker-v2-manifest-list",
+	)
+
+	tests := []struct {
+		name    string
+		src     name.Reference
+		wantErr error
+	}{
+		{
+			name:    "FromImage",
+			src:     refs["hello-world-docker-v2-manifest"],
+			wantErr: ErrUnsupportedMediaType,
+		},
+		{
+			name:    "FromIndex",
+			src:     refs["hello-world-docker-v2-manifest-list"],
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := RegistryFromReference(tt.src)
+			if err != nil {
+				t.Fatalf("RegistryFromReference() error = %v", err)
+			}
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatalf(".Get() error = %v", err)
+			}
+
+			_, err = d.ImageIndex()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf(".ImageIndex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistryDescriptorContext(t *testing.T) {
+	host := newTestRegistry(t)
+	refs := pushTestImages(t, host,
+		"hello-world-docker-v2-manifest",
+		"hello-world-docker-v2-manifest-list",
+	)
+
+	tests := []struct {
+		name string
+		src  name.Reference
+		get  func(Descriptor) error
+	}{
+		{
+			name: "ImageFromImage",
+			src:  refs["hello-world-docker-v2-manifest"],
+			get: func(d Descriptor) error {
+				_, err := d.Image()
+				return err
+			},
+		},
+		{
+			name: "ImageFromIndex",
+			src:  refs["hello-world-docker-v2-manifest-list"],
+			get: func(d Descriptor) error {
+				_, err := d.Image()
+				return err
+			},
+		},
+		{
+			name: "ImageIndex",
+			src:  refs["hello-world-docker-v2-manifest-list"],
+			get: func(d Descriptor) error {
+				_, err := d.ImageIndex()
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := RegistryFromReference(tt.src)
+			if err != nil {
+				t.Fatalf("RegistryFromReference() error = %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			d, err := s.Get(ctx)
+			if err != nil {
+				t.Fatalf(".Get() error = %v", err)
+			}
+
+			// Once the context passed to Get is cancelled, the image or index
+			// must not be retrieved.
+			cancel()
+
+			if err := tt.get(d); !errors.Is(err, context.Canceled) {
+				t.Errorf("got error %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestRegistryWrite(t *testing.T) {
+	host := newTestRegistry(t)
+	repo, err := name.NewRepository(host + "/my/image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := repo.Tag("latest")
+	otherRef := repo.Tag("other")
+
+	tests := []struct {
+		name    string
+		w       Writable
+		opts    []WriteOpt
+		wantRef name.Tag
+	}{
+		{
+			name:    "ImageDefaults",
+			w:       corpus.Image(t, "hello-world-docker-v2-manifest"),
+			opts:    []WriteOpt{},
+			wantRef: ref,
+		},
+		{
+			name:    "ImageWithReference",
+			w:       corpus.Image(t, "hello-world-docker-v2-manifest"),
+			opts:    []WriteOpt{WriteWithReference(otherRef)},
+			wantRef: otherRef,
+		},
+		{
+			name:    "IndexDefaults",
+			w:       corpus.ImageIndex(t, "hello-world-docker-v2-manifest-list"),
+			opts:    []WriteOpt{},
+			wantRef: ref,
+		},
+		{
+			name:    "IndexWithReference",
+			w:       corpus.ImageIndex(t, "hello-world-docker-v2-manifest-list"),
+			opts:    []WriteOpt{WriteWithReference(otherRef)},
+			wantRef: otherRef,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := RegistryFromReference(ref)
+			if err != nil {
+				t.Fatalf("RegistryFromReference() error = %v", err)
+			}
+			if err := s.Write(t.Context(), tt.w, tt.opts...); err != nil {
+				t.Fatalf(".Write() error = %v", err)
+			}
+
+			d, err := remote.Get(tt.wantRef)
+			if err != nil {
+				t.Fatalf("remote.Get() error = %v", err)
+			}
+
+			want, err := partial.Digest(tt.w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := d.Digest; got != want {
+				t.Errorf("got digest %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRegistryBlob(t *testing.T) {
+	host := newTestRegistry(t)
+	refs := pushTestImages(t, host, "hello-world-docker-v2-manifest")
+	ref := refs["hello-world-docker-v2-manifest"]
+
+	tests := []struct {
+		name    string
+		opts    []GetOpt
+		wantErr error
+	}{
+		{
+			name: "ImageConfig",
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "46331d942d6350436f64e614d75725f6de3bb5c63e266e236e04389820a234c4"})},
+			wantErr: nil,
+		},
+		{
+			name: "ImageLayer",
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff915842962cb813f32062d3bbdd35c750dd7d01"})},
+			wantErr: nil,
+		},
+		{
+			name:    "ErrNoDigest",
+			opts:    []GetOpt{},
+			wantErr: errBlobNoDigest,
+		},
+		{
+			name: "ErrPlatform",
+			opts: []GetOpt{
+				GetWithDigest(v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff95842962cb813f32062d3bbdd35c750dd7d01"}),
+				GetWithPlatform(*ociplatform.DefaultPlatform()),
+			},
+			wantErr: errBlobPlatform,
+		},
+		{
+			name: "ErrReference",
+			opts: []GetOpt{
+				GetWithDigest(v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff95842962cb813f32062d3bbdd35c750dd7d01"}),
+				GetWithReference(name.MustParseReference("test")),
+			},
+			wantErr: errBlobReference,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := RegistryFromReference(ref)
+			if err != nil {
+				t.Fatalf("RegistryFromReference() error = %v", err)
+			}
+			rc, err := s.Blob(t.Context(), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Blob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+			defer rc.Close()
+
+			b, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("ReadAll error: %v", err)
+			}
+
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, b)
+		})
+	}
+}
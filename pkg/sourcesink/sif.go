@@ -1,4 +1,4 @@
-// Copyright 2024-2025 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -11,16 +11,17 @@ import (
 	"io"
 	"log/slog"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sylabs/oci-tools/pkg/instrumented"
 	"github.com/sylabs/oci-tools/pkg/ociplatform"
 	ocisif "github.com/sylabs/oci-tools/pkg/sif"
-	"github.com/sylabs/sif/v2/pkg/sif"
 )
 
 // sifSourceSink is used to retrieve/write images and indexes from/to a SIF file.
@@ -51,12 +52,7 @@ func SIFFromPath(src string, opts ...Option) (SourceSink, error) {
 		return nil, err
 	}
 
-	fi, err := sif.LoadContainerFromPath(src)
-	if err != nil {
-		return nil, err
-	}
-
-	s.ofi, err = ocisif.FromFileImage(fi)
+	s.ofi, err = ocisif.LoadFromPath(src)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +62,8 @@ func SIFFromPath(src string, opts ...Option) (SourceSink, error) {
 
 // SIFEmpty will create a new, empty SIF file at dst, with a specified capacity
 // of descriptors, and return a sifSourceSink that can be used to write/read
-// to/from it.
+// to/from it. The descriptor capacity is grown automatically if it is
+// insufficient for content written to the sink.
 func SIFEmpty(dst string, descriptors int64, opts ...Option) (SourceSink, error) {
 	s, err := handleOptionsSIF(opts...)
 	if err != nil {
@@ -76,18 +73,17 @@ func SIFEmpty(dst string, descriptors int64, opts ...Option) (SourceSink, error)
 		return nil, err
 	}
 
-	fi, err := sif.LoadContainerFromPath(dst)
-	if err != nil {
-		return nil, err
-	}
-	s.ofi, err = ocisif.FromFileImage(fi)
+	s.ofi, err = ocisif.LoadFromPath(dst)
 	if err != nil {
 		return nil, err
 	}
 	return s, nil
 }
 
-var _ Descriptor = &sifDescriptor{}
+var (
+	_ Descriptor          = &sifDescriptor{}
+	_ ReferrersDescriptor = &sifDescriptor{}
+)
 
 // sifDescriptor wraps a v1.Descriptor, providing methods to access the image or
 // index to which it pertains, and the associated manifest, from an underlying
@@ -184,33 +180,15 @@ func (d *sifDescriptor) ImageIndex() (v1.ImageIndex, error) {
 	return ii, err
 }
 
-// getMatcher returns a Matcher that selects descriptors from an OCI layout
-// index.json according to the getOpts provided.
-func getMatcher(o getOpts) match.Matcher {
-	return func(desc v1.Descriptor) bool {
-		// Specified digest must match if provided.
-		if o.digest != nil && desc.Digest != *o.digest {
-			return false
-		}
-
-		if o.reference != nil {
-			// Specified reference must match if provided.
-			if desc.Annotations != nil || desc.Annotations[imagespec.AnnotationRefName] != o.reference.Name() {
-				return false
-			}
-		} else {
-			// Otherwise, no ref.name annotation is set.
-			if desc.Annotations != nil && desc.Annotations[imagespec.AnnotationRefName] != "" {
-				return false
-			}
-		}
-
-		// If desc is an image, then must satisfy platform if specified.
-		if o.platform != nil && desc.MediaType.IsImage() {
-			return desc.Platform != nil && ociplatform.DescriptorSatisfies(desc, *o.platform)
-		}
-		return true
+// Referrers returns an index holding a descriptor for each manifest in the SIF
+// file that has the image or index with this descriptor as its subject. If
+// artifactType is not empty, only referrers of that artifact type are returned.
+func (d *sifDescriptor) Referrers(_ context.Context, artifactType string) (v1.ImageIndex, error) {
+	var m match.Matcher
+	if artifactType != "" {
+		m = ocisif.ArtifactType(artifactType)
 	}
+	return d.ofi.Referrers(d.descriptor.Digest, m)
 }
 
 // Get will find an image or index in the SIF file that matches the requirements
@@ -283,6 +261,47 @@ func (o *sifSourceSink) Get(_ context.Context, opts ...GetOpt) (Descriptor, erro
 	}
 }
 
+var _ Lister = &sifSourceSink{}
+
+// List returns a ListedDescriptor for each image or index referenced directly
+// from the root index of the SIF file, that matches the requirements specified
+// by opts.
+func (o *sifSourceSink) List(_ context.Context, opts ...GetOpt) ([]ListedDescriptor, error) {
+	gOpts := getOpts{}
+	for _, opt := range opts {
+		if err := opt(&gOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	ds, err := o.ofi.FindManifests(listMatcher(gOpts))
+	if err != nil {
+		return nil, err
+	}
+
+	lds := make([]ListedDescriptor, 0, len(ds))
+	for _, d := range ds {
+		mf, err := o.ofi.Bytes(d.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		lds = append(lds, ListedDescriptor{
+			Descriptor: &sifDescriptor{
+				descriptor:            d,
+				Manifest:              mf,
+				ofi:                   o.ofi,
+				instrumentationLogger: o.opts.instrumentationLogger,
+			},
+			Digest:   d.Digest,
+			RefName:  d.Annotations[imagespec.AnnotationRefName],
+			Platform: d.Platform,
+		})
+	}
+
+	return lds, nil
+}
+
 func (o *sifSourceSink) imageFromIndex(ii v1.ImageIndex, p *v1.Platform) (Descriptor, error) {
 	iiDigest, err := ii.Digest()
 	if err != nil {
@@ -339,87 +358,84 @@ func (o *sifSourceSink) Write(_ context.Context, w Writable, opts ...WriteOpt) e
 	return ErrUnsupportedMediaType
 }
 
-// NumDescriptorsForImage returns the number of descriptors required to store img.
-func NumDescriptorsForImage(img v1.Image) (int64, error) {
-	ls, err := img.Layers()
-	if err != nil {
-		return 0, err
+var _ Deleter = &sifSourceSink{}
+
+// Delete will remove the image or index in the SIF file that matches the
+// requirements specified by opts. Only images and indexes referenced directly
+// from the root index of the SIF file can be removed. Blobs that are no longer
+// referenced are removed from the SIF file.
+func (o *sifSourceSink) Delete(_ context.Context, opts ...GetOpt) error {
+	gOpts := getOpts{}
+	for _, opt := range opts {
+		if err := opt(&gOpts); err != nil {
+			return err
+		}
 	}
 
-	return int64(len(ls) + 2), nil
-}
+	gm := getMatcher(gOpts)
+	m := func(desc v1.Descriptor) bool {
+		// If a platform is specified, only an image can satisfy it.
+		if gOpts.platform != nil && !desc.MediaType.IsImage() {
+			return false
+		}
+		return gm(desc)
+	}
 
-// NumDescriptorsForIndex returns the number of descriptors required to store ii.
-func NumDescriptorsForIndex(ii v1.ImageIndex) (int64, error) {
-	index, err := ii.IndexManifest()
+	ds, err := o.ofi.FindManifests(m)
 	if err != nil {
-		return 0, err
+		return err
+	}
+	if len(ds) == 0 {
+		return ErrNoManifest
+	}
+	if len(ds) > 1 {
+		return ErrMultipleManifests
 	}
 
-	var count int64
-
-	for _, desc := range index.Manifests {
-		//nolint:exhaustive // Exhaustive cases not appropriate.
-		switch desc.MediaType {
-		case types.DockerManifestList, types.OCIImageIndex:
-			ii, err := ii.ImageIndex(desc.Digest)
-			if err != nil {
-				return 0, err
-			}
-
-			n, err := NumDescriptorsForIndex(ii)
-			if err != nil {
-				return 0, err
-			}
-
-			count += n
+	return o.ofi.RemoveManifests(m)
+}
 
-		case types.DockerManifestSchema2, types.OCIManifestSchema1:
-			img, err := ii.Image(desc.Digest)
-			if err != nil {
-				return 0, err
-			}
+// Untag will remove the reference r from the image or index in the SIF file
+// that it refers to. The image or index itself is retained.
+func (o *sifSourceSink) Untag(_ context.Context, r name.Reference) error {
+	err := o.ofi.Untag(r)
+	if errors.Is(err, ocisif.ErrNoMatch) {
+		return fmt.Errorf("%w: %w", ErrNoManifest, err)
+	}
+	return err
+}
 
-			n, err := NumDescriptorsForImage(img)
-			if err != nil {
-				return 0, err
-			}
+// NumDescriptorsForImage returns the number of descriptors required to store
+// img. Blobs that appear more than once in img are stored, and counted, once.
+func NumDescriptorsForImage(img v1.Image) (int64, error) {
+	n, err := NumDescriptorsForIndex(mutate.AppendManifests(empty.Index, mutate.IndexAddendum{Add: img}))
+	if err != nil {
+		return 0, err
+	}
 
-			count += n
+	// Exclude the descriptor of the index that wraps img.
+	return n - 1, nil
+}
 
-		default:
-			count++
-		}
+// NumDescriptorsForIndex returns the number of descriptors required to store
+// ii. Blobs that appear more than once in ii are stored, and counted, once, as
+// by ocisif.Write.
+func NumDescriptorsForIndex(ii v1.ImageIndex) (int64, error) {
+	e, err := ocisif.Estimate(ii)
+	if err != nil {
+		return 0, err
 	}
 
-	return count + 1, nil
+	return e.Descriptors, nil
 }
 
-var (
-	errSIFBlobNoDigest  = errors.New("a digest must be provided to get a blob")
-	errSIFBlobReference = errors.New("a reference cannot be provided when getting a blob")
-	errSIFBlobPlatform  = errors.New("a platform cannot be provided when getting a blob")
-)
-
 // Blob returns an io.Readcloser for the content of the blob with a digest
 // specified using the GetWithDigest option.
 func (o *sifSourceSink) Blob(_ context.Context, opts ...GetOpt) (io.ReadCloser, error) {
-	gOpts := getOpts{}
-	for _, opt := range opts {
-		if err := opt(&gOpts); err != nil {
-			return nil, err
-		}
-	}
-
-	if gOpts.reference != nil {
-		return nil, errSIFBlobReference
-	}
-	if gOpts.platform != nil {
-		return nil, errSIFBlobPlatform
-	}
-	if gOpts.digest == nil {
-		return nil, errSIFBlobNoDigest
+	h, err := blobDigest(opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	return o.ofi.Blob(*gOpts.digest)
+	return o.ofi.Blob(h)
 }
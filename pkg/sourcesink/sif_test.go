@@ -1,11 +1,13 @@
-// Copyright 2024-2025 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
 package sourcesink
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
@@ -14,9 +16,14 @@ import (
 
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/sebdah/goldie/v2"
 	"github.com/sylabs/oci-tools/pkg/ociplatform"
+	ocisif "github.com/sylabs/oci-tools/pkg/sif"
 	"github.com/sylabs/oci-tools/test"
+	"github.com/sylabs/sif/v2/pkg/sif"
 )
 
 //nolint:gochecknoglobals
@@ -53,6 +60,10 @@ func TestSIFFromPath(t *testing.T) {
 func TestSIFGet(t *testing.T) {
 	imgDigest := v1.Hash{Algorithm: "sha256", Hex: "432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338"}
 	idxDigest := v1.Hash{Algorithm: "sha256", Hex: "00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed"}
+	sigRef := name.MustParseReference(
+		"_cosign:sha256-432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338.sig",
+		name.WithDefaultRegistry(""),
+	)
 
 	tests := []struct {
 		name    string
@@ -90,6 +101,18 @@ func TestSIFGet(t *testing.T) {
 			opts:    []GetOpt{GetWithDigest(v1.Hash{})},
 			wantErr: ErrNoManifest,
 		},
+		{
+			name:    "ImageReference",
+			src:     corpus.SIF(t, "hello-world-cosign-manifest"),
+			opts:    []GetOpt{GetWithReference(sigRef)},
+			wantErr: nil,
+		},
+		{
+			name:    "ImageBadReference",
+			src:     corpus.SIF(t, "hello-world-docker-v2-manifest"),
+			opts:    []GetOpt{GetWithReference(sigRef)},
+			wantErr: ErrNoManifest,
+		},
 		{
 			name:    "IndexDefaults",
 			src:     corpus.SIF(t, "hello-world-docker-v2-manifest-list"),
@@ -146,6 +169,250 @@ func TestSIFGet(t *testing.T) {
 	}
 }
 
+func TestSIFList(t *testing.T) {
+	sigRef := name.MustParseReference(
+		"_cosign:sha256-00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed.sig",
+		name.WithDefaultRegistry(""),
+	)
+
+	tests := []struct {
+		name string
+		src  string
+		opts []GetOpt
+	}{
+		{
+			name: "All",
+			src:  corpus.SIF(t, "hello-world-cosign-manifest-list"),
+			opts: []GetOpt{},
+		},
+		{
+			name: "Reference",
+			src:  corpus.SIF(t, "hello-world-cosign-manifest-list"),
+			opts: []GetOpt{GetWithReference(sigRef)},
+		},
+		{
+			name: "Digest",
+			src:  corpus.SIF(t, "hello-world-cosign-manifest-list"),
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed"})},
+		},
+		{
+			name: "Platform",
+			src:  corpus.SIF(t, "hello-world-docker-v2-manifest"),
+			opts: []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "arm64"})},
+		},
+		{
+			name: "BadPlatform",
+			src:  corpus.SIF(t, "hello-world-docker-v2-manifest"),
+			opts: []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "m68k"})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := SIFFromPath(tt.src)
+			if err != nil {
+				t.Fatalf("SIFFromPath() error = %v", err)
+			}
+
+			l, ok := s.(Lister)
+			if !ok {
+				t.Fatalf("%T is not a Lister", s)
+			}
+
+			lds, err := l.List(t.Context(), tt.opts...)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, formatListed(lds))
+		})
+	}
+}
+
+// formatListed returns a line for each of lds, describing its digest, media
+// type, ref.name and platform.
+func formatListed(lds []ListedDescriptor) []byte {
+	var b bytes.Buffer
+	for _, ld := range lds {
+		fmt.Fprintf(&b, "%v %v %q", ld.Digest, ld.MediaType(), ld.RefName)
+		if ld.Platform != nil {
+			fmt.Fprintf(&b, " %v", ld.Platform)
+		}
+		fmt.Fprintln(&b)
+	}
+	return b.Bytes()
+}
+
+// numSIFBlobs returns the number of OCI blobs held in the SIF file at path.
+func numSIFBlobs(t *testing.T, path string) int {
+	t.Helper()
+
+	fi, err := sif.LoadContainerFromPath(path, sif.OptLoadWithFlag(os.O_RDONLY))
+	if err != nil {
+		t.Fatalf("LoadContainerFromPath() error = %v", err)
+	}
+	defer fi.UnloadContainer()
+
+	ds, err := fi.GetDescriptors(sif.WithDataType(sif.DataOCIBlob))
+	if err != nil {
+		t.Fatalf("GetDescriptors() error = %v", err)
+	}
+	return len(ds)
+}
+
+func TestSIFDelete(t *testing.T) {
+	sigRef := name.MustParseReference(
+		"_cosign:sha256-00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed.sig",
+		name.WithDefaultRegistry(""),
+	)
+
+	tests := []struct {
+		name      string
+		src       string
+		opts      []GetOpt
+		wantErr   error
+		wantBlobs int
+	}{
+		{
+			name:      "Image",
+			src:       "hello-world-docker-v2-manifest",
+			opts:      []GetOpt{},
+			wantBlobs: 0,
+		},
+		{
+			name:      "ImagePlatform",
+			src:       "hello-world-docker-v2-manifest",
+			opts:      []GetOpt{GetWithPlatform(v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"})},
+			wantBlobs: 0,
+		},
+		{
+			name: "Index",
+			src:  "hello-world-cosign-manifest-list",
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed"})},
+			wantBlobs: 33,
+		},
+		{
+			name:      "Reference",
+			src:       "hello-world-cosign-manifest-list",
+			opts:      []GetOpt{GetWithReference(sigRef)},
+			wantBlobs: 58,
+		},
+		{
+			name:      "IndexPlatform",
+			src:       "hello-world-cosign-manifest-list",
+			opts:      []GetOpt{GetWithPlatform(v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"})},
+			wantErr:   ErrNoManifest,
+			wantBlobs: 61,
+		},
+		{
+			name: "BadDigest",
+			src:  "hello-world-docker-v2-manifest",
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "0000000000000000000000000000000000000000000000000000000000000000"})},
+			wantErr:   ErrNoManifest,
+			wantBlobs: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := corpus.SIF(t, tt.src)
+
+			s, err := SIFFromPath(path)
+			if err != nil {
+				t.Fatalf("SIFFromPath() error = %v", err)
+			}
+
+			d, ok := s.(Deleter)
+			if !ok {
+				t.Fatalf("%T is not a Deleter", s)
+			}
+
+			if err := d.Delete(t.Context(), tt.opts...); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			s, err = SIFFromPath(path)
+			if err != nil {
+				t.Fatalf("SIFFromPath() error = %v", err)
+			}
+
+			lds, err := s.(Lister).List(t.Context())
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, formatListed(lds))
+
+			if got, want := numSIFBlobs(t, path), tt.wantBlobs; got != want {
+				t.Errorf("got %v blobs, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSIFUntag(t *testing.T) {
+	sigRef := name.MustParseReference(
+		"_cosign:sha256-00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed.sig",
+		name.WithDefaultRegistry(""),
+	)
+
+	tests := []struct {
+		name    string
+		ref     name.Reference
+		wantErr error
+	}{
+		{
+			name: "Reference",
+			ref:  sigRef,
+		},
+		{
+			name:    "MissingReference",
+			ref:     sigRef.Context().Tag("missing"),
+			wantErr: ErrNoManifest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := corpus.SIF(t, "hello-world-cosign-manifest-list")
+			blobs := numSIFBlobs(t, path)
+
+			s, err := SIFFromPath(path)
+			if err != nil {
+				t.Fatalf("SIFFromPath() error = %v", err)
+			}
+
+			if err := s.(Deleter).Untag(t.Context(), tt.ref); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Untag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			s, err = SIFFromPath(path)
+			if err != nil {
+				t.Fatalf("SIFFromPath() error = %v", err)
+			}
+
+			if _, err := s.Get(t.Context(), GetWithReference(tt.ref)); !errors.Is(err, ErrNoManifest) {
+				t.Errorf("Get() error = %v, want %v", err, ErrNoManifest)
+			}
+
+			lds, err := s.(Lister).List(t.Context())
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, formatListed(lds))
+
+			// Untag must not remove any blobs.
+			if got, want := numSIFBlobs(t, path), blobs; got != want {
+				t.Errorf("got %v blobs, want %v", got, want)
+			}
+		})
+	}
+}
+
 func TestSIFDescriptorImage(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -222,6 +489,81 @@ func TestSIFDescriptorImageIndex(t *testing.T) {
 	}
 }
 
+func TestSIFDescriptorReferrers(t *testing.T) {
+	path := corpus.SIF(t, "hello-world-docker-v2-manifest", ocisif.OptWriteWithSpareDescriptorCapacity(8))
+
+	fi, err := sif.LoadContainerFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ofi, err := ocisif.FromFileImage(fi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := ofi.FindManifests(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mt := range []types.MediaType{"application/vnd.example.sbom", "application/vnd.example.sig"} {
+		img := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), mt)
+		if err := ofi.AppendImage(img, ocisif.OptAppendSubject(ds[0])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fi.UnloadContainer(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		artifactType  string
+		wantReferrers int
+	}{
+		{
+			name:          "All",
+			wantReferrers: 2,
+		},
+		{
+			name:          "ArtifactType",
+			artifactType:  "application/vnd.example.sbom",
+			wantReferrers: 1,
+		},
+		{
+			name:          "UnknownArtifactType",
+			artifactType:  "application/vnd.example.unknown",
+			wantReferrers: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := SIFFromPath(path)
+			if err != nil {
+				t.Fatalf("SIFFromPath() error = %v", err)
+			}
+			d, err := s.Get(t.Context(), GetWithDigest(ds[0].Digest))
+			if err != nil {
+				t.Fatalf(".Get() error = %v", err)
+			}
+
+			rd, ok := d.(ReferrersDescriptor)
+			if !ok {
+				t.Fatal("descriptor does not implement ReferrersDescriptor")
+			}
+			ii, err := rd.Referrers(t.Context(), tt.artifactType)
+			if err != nil {
+				t.Fatalf(".Referrers() error = %v", err)
+			}
+			im, err := ii.IndexManifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(im.Manifests), tt.wantReferrers; got != want {
+				t.Errorf("got %v referrers, want %v", got, want)
+			}
+		})
+	}
+}
+
 func TestSIFEmpty(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -303,6 +645,82 @@ func TestSIFWrite(t *testing.T) {
 	}
 }
 
+func TestNumDescriptors(t *testing.T) {
+	img := corpus.Image(t, "hello-world-docker-v2-manifest")
+
+	ls, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An image in which the same layer appears twice.
+	dupImg, err := mutate.AppendLayers(img, ls[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		img  v1.Image
+		ii   v1.ImageIndex
+	}{
+		{
+			name: "Image",
+			img:  img,
+		},
+		{
+			name: "ImageDuplicateLayer",
+			img:  dupImg,
+		},
+		{
+			name: "Index",
+			ii:   corpus.ImageIndex(t, "hello-world-docker-v2-manifest-list"),
+		},
+		{
+			name: "IndexDuplicateLayer",
+			ii: mutate.AppendManifests(empty.Index,
+				mutate.IndexAddendum{Add: img},
+				mutate.IndexAddendum{Add: dupImg},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ii := tt.ii
+
+			var got int64
+			var err error
+			if tt.img != nil {
+				// The image is written within a RootIndex, which requires a descriptor
+				// of its own.
+				ii = mutate.AppendManifests(empty.Index, mutate.IndexAddendum{Add: tt.img})
+				got, err = NumDescriptorsForImage(tt.img)
+				got++
+			} else {
+				got, err = NumDescriptorsForIndex(ii)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(t.TempDir(), "test.sif")
+			if err := ocisif.Write(path, ii); err != nil {
+				t.Fatal(err)
+			}
+
+			fi, err := sif.LoadContainerFromPath(path, sif.OptLoadWithFlag(os.O_RDONLY))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = fi.UnloadContainer() })
+
+			if want := fi.DescriptorsTotal() - fi.DescriptorsFree(); got != want {
+				t.Errorf("got %v descriptors, want %v", got, want)
+			}
+		})
+	}
+}
+
 func TestSIFBlob(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -328,7 +746,7 @@ func TestSIFBlob(t *testing.T) {
 			name:    "ErrNoDigest",
 			src:     corpus.SIF(t, "hello-world-docker-v2-manifest"),
 			opts:    []GetOpt{},
-			wantErr: errSIFBlobNoDigest,
+			wantErr: errBlobNoDigest,
 		},
 		{
 			name: "ErrPlatform",
@@ -337,7 +755,7 @@ func TestSIFBlob(t *testing.T) {
 				GetWithDigest(v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff95842962cb813f32062d3bbdd35c750dd7d01"}),
 				GetWithPlatform(*ociplatform.DefaultPlatform()),
 			},
-			wantErr: errSIFBlobPlatform,
+			wantErr: errBlobPlatform,
 		},
 		{
 			name: "ErrReference",
@@ -346,14 +764,14 @@ func TestSIFBlob(t *testing.T) {
 				GetWithDigest(v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff95842962cb813f32062d3bbdd35c750dd7d01"}),
 				GetWithReference(name.MustParseReference("test")),
 			},
-			wantErr: errSIFBlobReference,
+			wantErr: errBlobReference,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s, err := SIFFromPath(tt.src)
 			if err != nil {
-				t.Fatalf("OCIFromPath() error = %v", err)
+				t.Fatalf("SIFFromPath() error = %v", err)
 			}
 			rc, err := s.Blob(t.Context(), tt.opts...)
 			if !errors.Is(err, tt.wantErr) {
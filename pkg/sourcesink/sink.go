@@ -1,4 +1,4 @@
-// Copyright 2024-2025 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -22,6 +22,19 @@ type Sink interface {
 	Write(ctx context.Context, w Writable, opts ...WriteOpt) error
 }
 
+// Deleter is implemented by sinks that can remove images and indexes, and
+// references to them.
+type Deleter interface {
+	// Delete will remove the image or index at the sink that matches the
+	// requirements specified by opts, using the same selection rules as Get.
+	// If GetWithPlatform is specified, only an image can be selected. Blobs
+	// that are no longer referenced are removed.
+	Delete(ctx context.Context, opts ...GetOpt) error
+	// Untag will remove the reference r from the image or index at the sink
+	// that it refers to. The image or index itself is retained.
+	Untag(ctx context.Context, r name.Reference) error
+}
+
 // writeOpts holds options that should apply across to a single Write operation
 // against a sink.
 type writeOpts struct {
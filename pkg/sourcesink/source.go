@@ -1,4 +1,4 @@
-// Copyright 2024-2025 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -11,7 +11,10 @@ import (
 
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/match"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sylabs/oci-tools/pkg/ociplatform"
 )
 
 // Descriptor wraps a v1.Descriptor, providing methods to access the image or
@@ -79,6 +82,109 @@ func GetWithReference(r name.Reference) GetOpt {
 	}
 }
 
+// getMatcher returns a Matcher that selects descriptors from an OCI layout
+// index.json according to the getOpts provided.
+func getMatcher(o getOpts) match.Matcher {
+	m := listMatcher(o)
+	return func(desc v1.Descriptor) bool {
+		// If no reference is specified, no ref.name annotation must be set.
+		if o.reference == nil && desc.Annotations != nil && desc.Annotations[imagespec.AnnotationRefName] != "" {
+			return false
+		}
+		return m(desc)
+	}
+}
+
+// listMatcher returns a Matcher that selects descriptors from an OCI layout
+// index.json according to the getOpts provided. Unlike getMatcher, if no
+// reference is specified, descriptors are selected regardless of any ref.name
+// annotation.
+func listMatcher(o getOpts) match.Matcher {
+	return func(desc v1.Descriptor) bool {
+		// Specified digest must match if provided.
+		if o.digest != nil && desc.Digest != *o.digest {
+			return false
+		}
+
+		// Specified reference must match if provided.
+		if o.reference != nil {
+			if desc.Annotations == nil || desc.Annotations[imagespec.AnnotationRefName] != o.reference.Name() {
+				return false
+			}
+		}
+
+		// If desc is an image, then must satisfy platform if specified.
+		if o.platform != nil && desc.MediaType.IsImage() {
+			return desc.Platform != nil && ociplatform.DescriptorSatisfies(desc, *o.platform)
+		}
+		return true
+	}
+}
+
+// Lister is implemented by sources that can enumerate the images and indexes
+// they hold.
+type Lister interface {
+	// List returns a ListedDescriptor for each image or index at the source
+	// that matches the requirements specified by opts. Unlike Get, if
+	// GetWithReference is not specified then images and indexes are listed
+	// regardless of their reference, and if GetWithPlatform is specified then
+	// indexes are listed, rather than the images they contain.
+	List(ctx context.Context, opts ...GetOpt) ([]ListedDescriptor, error)
+}
+
+// ListedDescriptor is a Descriptor returned by List, together with the
+// properties of the image or index recorded at the source.
+type ListedDescriptor struct {
+	Descriptor
+
+	// Digest is the digest of the manifest.
+	Digest v1.Hash
+	// RefName is the value of the `org.opencontainers.image.ref.name`
+	// annotation at the source, or empty if there is no such annotation.
+	RefName string
+	// Platform is the platform recorded at the source, if any.
+	Platform *v1.Platform
+}
+
+// ReferrersDescriptor is implemented by descriptors that can enumerate the
+// manifests which refer to them via an OCI subject field.
+type ReferrersDescriptor interface {
+	// Referrers returns an index holding a descriptor for each manifest at the
+	// source that has the image or index with this descriptor as its subject.
+	// If artifactType is not empty, only referrers of that artifact type are
+	// returned.
+	Referrers(ctx context.Context, artifactType string) (v1.ImageIndex, error)
+}
+
+var (
+	errBlobNoDigest  = errors.New("a digest must be provided to get a blob")
+	errBlobReference = errors.New("a reference cannot be provided when getting a blob")
+	errBlobPlatform  = errors.New("a platform cannot be provided when getting a blob")
+)
+
+// blobDigest returns the digest of the blob selected by opts. A digest must be
+// specified using GetWithDigest, and a reference or platform must not be set.
+func blobDigest(opts ...GetOpt) (v1.Hash, error) {
+	gOpts := getOpts{}
+	for _, opt := range opts {
+		if err := opt(&gOpts); err != nil {
+			return v1.Hash{}, err
+		}
+	}
+
+	if gOpts.reference != nil {
+		return v1.Hash{}, errBlobReference
+	}
+	if gOpts.platform != nil {
+		return v1.Hash{}, errBlobPlatform
+	}
+	if gOpts.digest == nil {
+		return v1.Hash{}, errBlobNoDigest
+	}
+
+	return *gOpts.digest, nil
+}
+
 var (
 	// ErrNoManifest is returned when no manifests that satisfy provided
 	// criteria are found in a source.
@@ -1,4 +1,4 @@
-// Copyright 2024-2025 Sylabs Inc. All rights reserved.
+// Copyright 2024-2026 Sylabs Inc. All rights reserved.
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -6,6 +6,9 @@ package sourcesink
 
 import (
 	"log/slog"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
 )
 
 // SourceSink implements methods to read / write images and indexes from / to a
@@ -19,6 +22,11 @@ type SourceSink interface {
 // operations against a source or sink.
 type options struct {
 	instrumentationLogger *slog.Logger
+
+	// Registry options.
+	keychain  authn.Keychain
+	insecure  bool
+	transport http.RoundTripper
 }
 
 // Option sets an option that applies across multiple Get / Write operations against
@@ -32,3 +40,31 @@ func OptWithInstrumentationLogs(l *slog.Logger) Option {
 		return nil
 	}
 }
+
+// OptWithKeychain sets the keychain used to obtain credentials when accessing a
+// registry. By default, authn.DefaultKeychain is used.
+func OptWithKeychain(kc authn.Keychain) Option {
+	return func(o *options) error {
+		o.keychain = kc
+		return nil
+	}
+}
+
+// OptWithInsecure allows access to a registry over plain HTTP, or with TLS
+// certificates that cannot be verified. If OptWithTransport is also specified,
+// the transport must be an *http.Transport, a copy of which is used with TLS
+// certificate verification disabled.
+func OptWithInsecure(b bool) Option {
+	return func(o *options) error {
+		o.insecure = b
+		return nil
+	}
+}
+
+// OptWithTransport sets the http.RoundTripper used when accessing a registry.
+func OptWithTransport(rt http.RoundTripper) Option {
+	return func(o *options) error {
+		o.transport = rt
+		return nil
+	}
+}
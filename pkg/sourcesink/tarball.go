@@ -0,0 +1,781 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sylabs/oci-tools/pkg/instrumented"
+	"github.com/sylabs/oci-tools/pkg/ociplatform"
+)
+
+// annotationContainerdImageName is set by docker on descriptors in the
+// index.json of an archive created with `docker save`, and holds the full name
+// of the image.
+const annotationContainerdImageName = "io.containerd.image.name"
+
+var (
+	errTarballFormat      = errors.New("tar archive does not contain an OCI image layout or docker image")
+	errTarballWriteDocker = errors.New("cannot write to a docker archive")
+)
+
+// tarballSourceSink is used to retrieve/write images and indexes from/to a tar
+// archive, holding either an OCI image layout, or images created using
+// `docker save`.
+type tarballSourceSink struct {
+	a    *tarballArchive
+	opts options
+}
+
+var _ SourceSink = &tarballSourceSink{}
+
+func handleOptionsTarball(opts ...Option) (*tarballSourceSink, error) {
+	ss := tarballSourceSink{
+		opts: options{},
+	}
+	for _, opt := range opts {
+		if err := opt(&ss.opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ss, nil
+}
+
+// TarballFromPath returns a tarballSourceSink backed by an existing,
+// uncompressed, tar archive at src. The archive may hold an OCI image layout,
+// or images in the format written by `docker save`.
+//
+// Images in a docker archive are referenced by the names held in its RepoTags.
+// Only archives holding an OCI image layout, without a docker manifest.json,
+// may be written to.
+func TarballFromPath(src string, opts ...Option) (SourceSink, error) {
+	s, err := handleOptionsTarball(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.a, err = openTarballArchive(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.isOCI() && !s.isDocker() {
+		return nil, fmt.Errorf("%w: %v", errTarballFormat, src)
+	}
+
+	return s, nil
+}
+
+// TarballEmpty will create a new tar archive at dst, holding an empty OCI image
+// layout, and return a tarballSourceSink that can be used to write/read to/from
+// it.
+func TarballEmpty(dst string, opts ...Option) (SourceSink, error) {
+	s, err := handleOptionsTarball(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	im, err := empty.Index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	tw := tar.NewWriter(f)
+	if err := writeTarballLayoutFiles(tw, im); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	s.a, err = openTarballArchive(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// isOCI returns true if the archive holds an OCI image layout.
+func (o *tarballSourceSink) isOCI() bool {
+	return o.a.has(imagespec.ImageLayoutFile) && o.a.has("index.json")
+}
+
+// isDocker returns true if the archive holds a docker manifest.json.
+func (o *tarballSourceSink) isDocker() bool {
+	return o.a.has("manifest.json")
+}
+
+// rootIndex returns an index of the manifests held in the archive. If the
+// archive holds an OCI image layout, this is its index.json. Otherwise, an index
+// is constructed from the docker manifest.json, with a descriptor for each of
+// the RepoTags of each image.
+func (o *tarballSourceSink) rootIndex() (v1.ImageIndex, error) {
+	if o.isOCI() {
+		return o.a.rootIndex()
+	}
+
+	b, err := o.a.bytes("manifest.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var m tarball.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	adds := make([]mutate.IndexAddendum, 0, len(m))
+	for i, d := range m {
+		img, err := o.dockerImage(m, i)
+		if err != nil {
+			return nil, err
+		}
+
+		cf, err := img.ConfigFile()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(d.RepoTags) == 0 {
+			adds = append(adds, mutate.IndexAddendum{
+				Add:        img,
+				Descriptor: v1.Descriptor{Platform: cf.Platform()},
+			})
+		}
+
+		for _, t := range d.RepoTags {
+			tag, err := name.NewTag(t)
+			if err != nil {
+				return nil, err
+			}
+
+			adds = append(adds, mutate.IndexAddendum{
+				Add: img,
+				Descriptor: v1.Descriptor{
+					Platform:    cf.Platform(),
+					Annotations: map[string]string{imagespec.AnnotationRefName: tag.Name()},
+				},
+			})
+		}
+	}
+
+	return mutate.AppendManifests(empty.Index, adds...), nil
+}
+
+// dockerImage returns the image described by entry i of the docker
+// manifest.json m.
+func (o *tarballSourceSink) dockerImage(m tarball.Manifest, i int) (v1.Image, error) {
+	opener := func() (io.ReadCloser, error) {
+		return os.Open(o.a.path)
+	}
+
+	// The tarball package can only select an image by tag in a multi-image
+	// archive.
+	if len(m) == 1 {
+		return tarball.Image(opener, nil)
+	}
+	if len(m[i].RepoTags) > 0 {
+		tag, err := name.NewTag(m[i].RepoTags[0])
+		if err != nil {
+			return nil, err
+		}
+		return tarball.Image(opener, &tag)
+	}
+
+	// For an untagged image, prepend a manifest.json holding only its entry,
+	// which takes precedence over the manifest.json in the archive.
+	b, err := json.Marshal(tarball.Manifest{m[i]})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := writeTarballFile(tw, "manifest.json", b); err != nil {
+		return nil, err
+	}
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+
+	return tarball.Image(func() (io.ReadCloser, error) {
+		f, err := os.Open(o.a.path)
+		if err != nil {
+			return nil, err
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{
+			Reader: io.MultiReader(bytes.NewReader(buf.Bytes()), f),
+			Closer: f,
+		}, nil
+	}, nil)
+}
+
+var _ Descriptor = &tarballDescriptor{}
+
+// tarballDescriptor wraps a v1.Descriptor, providing methods to access the
+// image or index to which it pertains, and the associated manifest, from an
+// underlying tar archive.
+type tarballDescriptor struct {
+	descriptor v1.Descriptor
+	Manifest   []byte
+
+	ri v1.ImageIndex
+
+	instrumentationLogger *slog.Logger
+}
+
+// RawManifest returns the manifest of the image or index described by this
+// descriptor.
+func (d *tarballDescriptor) RawManifest() ([]byte, error) {
+	return d.Manifest, nil
+}
+
+// MediaType returns the types.MediaType of this descriptor.
+func (d *tarballDescriptor) MediaType() types.MediaType {
+	return d.descriptor.MediaType
+}
+
+// Image returns a v1.Image directly if the descriptor is associated with an
+// OCI image, or an image for the local platform if the descriptor is
+// associated with an OCI ImageIndex.
+func (d *tarballDescriptor) Image() (v1.Image, error) {
+	switch {
+	case d.descriptor.MediaType.IsImage():
+		ims, err := partial.FindImages(d.ri, match.Digests(d.descriptor.Digest))
+		if err != nil {
+			return nil, err
+		}
+		if len(ims) == 0 {
+			return nil, ErrNoManifest
+		}
+		if d.instrumentationLogger != nil {
+			return instrumented.Image(ims[0], d.instrumentationLogger)
+		}
+		return ims[0], nil
+
+	case d.descriptor.MediaType.IsIndex():
+		ii, err := findIndex(d.ri, d.descriptor.Digest)
+		if err != nil {
+			return nil, err
+		}
+		p := ociplatform.DefaultPlatform()
+		ims, err := partial.FindImages(ii, ociplatform.Matcher(p))
+		if err != nil {
+			return nil, err
+		}
+		if n := len(ims); n == 0 {
+			return nil, ErrNoManifest
+		} else if n > 1 {
+			return nil, ErrMultipleManifests
+		}
+		if d.instrumentationLogger != nil {
+			return instrumented.Image(ims[0], d.instrumentationLogger)
+		}
+		return ims[0], nil
+
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+}
+
+// ImageIndex returns a v1.ImageIndex if the descriptor is associated with
+// an OCI ImageIndex.
+func (d *tarballDescriptor) ImageIndex() (v1.ImageIndex, error) {
+	if !d.descriptor.MediaType.IsIndex() {
+		return nil, ErrUnsupportedMediaType
+	}
+	ii, err := findIndex(d.ri, d.descriptor.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if d.instrumentationLogger != nil {
+		return instrumented.Index(ii, d.instrumentationLogger)
+	}
+	return ii, nil
+}
+
+// tarballMatcher returns a Matcher that selects descriptors from the root index
+// of a tar archive according to the getOpts provided. An image name recorded
+// by `docker save` in an `io.containerd.image.name` annotation is matched as if
+// it were the `org.opencontainers.image.ref.name` annotation.
+func tarballMatcher(o getOpts) match.Matcher {
+	m := getMatcher(o)
+	return func(desc v1.Descriptor) bool {
+		if n, ok := desc.Annotations[annotationContainerdImageName]; ok {
+			if ref, err := name.ParseReference(n); err == nil {
+				desc.Annotations = maps.Clone(desc.Annotations)
+				desc.Annotations[imagespec.AnnotationRefName] = ref.Name()
+			}
+		}
+		return m(desc)
+	}
+}
+
+// Get will find an image or index in the tar archive that matches the
+// requirements specified by opts. If GetWithPlatform is specified then the
+// Descriptor returned will always be an image that satisfies the platform.
+// Otherwise, the Descriptor returned can be an image or an index.
+func (o *tarballSourceSink) Get(_ context.Context, opts ...GetOpt) (Descriptor, error) {
+	gOpts := getOpts{}
+	for _, opt := range opts {
+		if err := opt(&gOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	ri, err := o.rootIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := partial.FindManifests(ri, tarballMatcher(gOpts))
+	if err != nil {
+		return nil, err
+	}
+	if len(ds) == 0 {
+		return nil, ErrNoManifest
+	}
+	if len(ds) > 1 {
+		return nil, ErrMultipleManifests
+	}
+
+	mt := ds[0].MediaType
+	switch {
+	case mt.IsImage():
+		img, err := ri.Image(ds[0].Digest)
+		if err != nil {
+			return nil, err
+		}
+		if gOpts.platform != nil {
+			if err := ociplatform.EnsureImageSatisfies(img, *gOpts.platform); err != nil {
+				return nil, err
+			}
+		}
+		mf, err := img.RawManifest()
+		if err != nil {
+			return nil, err
+		}
+		return &tarballDescriptor{
+			descriptor:            ds[0],
+			Manifest:              mf,
+			ri:                    ri,
+			instrumentationLogger: o.opts.instrumentationLogger,
+		}, nil
+	case mt.IsIndex():
+		ii, err := ri.ImageIndex(ds[0].Digest)
+		if err != nil {
+			return nil, err
+		}
+		// Platform wasn't requested - return the index itself.
+		if gOpts.platform == nil {
+			mf, err := ii.RawManifest()
+			if err != nil {
+				return nil, err
+			}
+			return &tarballDescriptor{
+				descriptor:            ds[0],
+				Manifest:              mf,
+				ri:                    ri,
+				instrumentationLogger: o.opts.instrumentationLogger,
+			}, nil
+		}
+		// Platform was requested - find an image in the index.
+		return o.imageFromIndex(ri, ii, gOpts.platform)
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedMediaType, mt)
+	}
+}
+
+func (o *tarballSourceSink) imageFromIndex(ri, ii v1.ImageIndex, p *v1.Platform) (Descriptor, error) {
+	ims, err := partial.FindImages(ii, ociplatform.Matcher(p))
+	if err != nil {
+		return nil, err
+	}
+	if n := len(ims); n == 0 {
+		return nil, ErrNoManifest
+	} else if n > 1 {
+		return nil, ErrMultipleManifests
+	}
+	d, err := partial.Descriptor(ims[0])
+	if err != nil {
+		return nil, err
+	}
+	mf, err := ims[0].RawManifest()
+	if err != nil {
+		return nil, err
+	}
+	return &tarballDescriptor{
+		descriptor:            *d,
+		Manifest:              mf,
+		ri:                    ri,
+		instrumentationLogger: o.opts.instrumentationLogger,
+	}, nil
+}
+
+// Write will write an image or index w to the OCI image layout held in the tar
+// archive, and reference it from the layout's index.json. The archive is
+// rewritten in full, and replaced once complete.
+//
+// If WriteWithReference is specified, the reference is set as an
+// `org.opencontainers.image.ref.name` annotation on the new descriptor, and is
+// removed from any existing descriptors in index.json.
+func (o *tarballSourceSink) Write(_ context.Context, w Writable, opts ...WriteOpt) error {
+	wOpts := writeOpts{}
+	for _, opt := range opts {
+		if err := opt(&wOpts); err != nil {
+			return err
+		}
+	}
+
+	if o.isDocker() {
+		return errTarballWriteDocker
+	}
+
+	var desc *v1.Descriptor
+	var err error
+	switch w := w.(type) {
+	case v1.Image:
+		desc, err = partial.Descriptor(w)
+	case v1.ImageIndex:
+		desc, err = partial.Descriptor(w)
+	default:
+		return ErrUnsupportedMediaType
+	}
+	if err != nil {
+		return err
+	}
+
+	ri, err := o.a.rootIndex()
+	if err != nil {
+		return err
+	}
+	im, err := ri.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	if err := o.rewrite(w, appendDescriptor(im, *desc, wOpts.reference)); err != nil {
+		return err
+	}
+
+	o.a, err = openTarballArchive(o.a.path)
+	return err
+}
+
+// rewrite replaces the tar archive with a copy, to which the blobs of w are
+// added, and in which index.json is replaced by im.
+func (o *tarballSourceSink) rewrite(w Writable, im *v1.IndexManifest) error {
+	src, err := os.Open(o.a.path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.CreateTemp(filepath.Dir(o.a.path), ".tarball-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dst.Name())
+
+	if err := dst.Chmod(fi.Mode().Perm()); err != nil {
+		_ = dst.Close()
+		return err
+	}
+
+	if err := rewriteTarball(dst, src, w, im); err != nil {
+		_ = dst.Close()
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(dst.Name(), o.a.path)
+}
+
+// rewriteTarball copies the files in the tarball read from r to dst, other than
+// the OCI layout files. The blobs of w are then added, followed by OCI layout
+// files describing im.
+func rewriteTarball(dst io.Writer, r io.Reader, w Writable, im *v1.IndexManifest) error {
+	tw := tar.NewWriter(dst)
+	written := make(map[string]bool)
+
+	// Copy existing files, other than the OCI layout files, which are written
+	// last.
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(hdr.Name)
+		if name == "index.json" || name == imagespec.ImageLayoutFile {
+			continue
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+		written[name] = true
+	}
+
+	var err error
+	switch w := w.(type) {
+	case v1.Image:
+		err = writeTarballImage(tw, w, written)
+	case v1.ImageIndex:
+		err = writeTarballIndex(tw, w, written)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := writeTarballLayoutFiles(tw, im); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// writeTarballFile writes a regular file called name, with content b, to tw.
+func writeTarballFile(tw *tar.Writer, name string, b []byte) error {
+	return writeTarballStream(tw, name, int64(len(b)), bytes.NewReader(b))
+}
+
+// writeTarballStream writes a regular file called name, of the specified size,
+// with content read from r, to tw.
+func writeTarballStream(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0o644,
+		ModTime:  time.Unix(0, 0),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// writeTarballLayoutFiles writes the oci-layout and index.json files of an OCI
+// image layout, with root index im, to tw.
+func writeTarballLayoutFiles(tw *tar.Writer, im *v1.IndexManifest) error {
+	b, err := json.Marshal(imagespec.ImageLayout{Version: imagespec.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	if err := writeTarballFile(tw, imagespec.ImageLayoutFile, b); err != nil {
+		return err
+	}
+
+	b, err = json.MarshalIndent(im, "", "   ")
+	if err != nil {
+		return err
+	}
+	return writeTarballFile(tw, "index.json", b)
+}
+
+// writeTarballBlob writes a blob with digest h, of the specified size, to tw,
+// unless it has already been written.
+func writeTarballBlob(tw *tar.Writer, h v1.Hash, size int64, open func() (io.ReadCloser, error), written map[string]bool) error {
+	name := blobPath(h)
+	if written[name] {
+		return nil
+	}
+
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := writeTarballStream(tw, name, size, rc); err != nil {
+		return err
+	}
+	written[name] = true
+	return nil
+}
+
+// writeTarballBytes writes a blob with content b to tw, unless it has already
+// been written.
+func writeTarballBytes(tw *tar.Writer, b []byte, written map[string]bool) error {
+	h, size, err := v1.SHA256(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	return writeTarballBlob(tw, h, size, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}, written)
+}
+
+// writeTarballImage writes the blobs of img to tw.
+func writeTarballImage(tw *tar.Writer, img v1.Image, written map[string]bool) error {
+	ls, err := img.Layers()
+	if err != nil {
+		return err
+	}
+	for _, l := range ls {
+		h, err := l.Digest()
+		if err != nil {
+			return err
+		}
+		size, err := l.Size()
+		if err != nil {
+			return err
+		}
+		if err := writeTarballBlob(tw, h, size, l.Compressed, written); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := img.RawConfigFile()
+	if err != nil {
+		return err
+	}
+	if err := writeTarballBytes(tw, cfg, written); err != nil {
+		return err
+	}
+
+	mf, err := img.RawManifest()
+	if err != nil {
+		return err
+	}
+	return writeTarballBytes(tw, mf, written)
+}
+
+// writeTarballIndex writes the blobs of ii, and of the images and indexes that
+// it references, to tw.
+func writeTarballIndex(tw *tar.Writer, ii v1.ImageIndex, written map[string]bool) error {
+	im, err := ii.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	for _, desc := range im.Manifests {
+		switch mt := desc.MediaType; {
+		case mt.IsImage():
+			img, err := ii.Image(desc.Digest)
+			if err != nil {
+				return err
+			}
+			if err := writeTarballImage(tw, img, written); err != nil {
+				return err
+			}
+		case mt.IsIndex():
+			child, err := ii.ImageIndex(desc.Digest)
+			if err != nil {
+				return err
+			}
+			if err := writeTarballIndex(tw, child, written); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: %v", ErrUnsupportedMediaType, mt)
+		}
+	}
+
+	mf, err := ii.RawManifest()
+	if err != nil {
+		return err
+	}
+	return writeTarballBytes(tw, mf, written)
+}
+
+// Blob returns an io.Readcloser for the content of the blob with a digest
+// specified using the GetWithDigest option.
+func (o *tarballSourceSink) Blob(_ context.Context, opts ...GetOpt) (io.ReadCloser, error) {
+	h, err := blobDigest(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.isOCI() {
+		return o.a.blob(h)
+	}
+
+	// Docker archives are not content addressable, so search for the blob in
+	// each image.
+	ri, err := o.rootIndex()
+	if err != nil {
+		return nil, err
+	}
+	ims, err := partial.FindImages(ri, func(v1.Descriptor) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+
+	for _, img := range ims {
+		if mh, err := img.Digest(); err == nil && mh == h {
+			mf, err := img.RawManifest()
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(bytes.NewReader(mf)), nil
+		}
+
+		if ch, err := img.ConfigName(); err == nil && ch == h {
+			cfg, err := img.RawConfigFile()
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(bytes.NewReader(cfg)), nil
+		}
+
+		if l, err := img.LayerByDigest(h); err == nil {
+			return l.Compressed()
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %v", errTarballFileNotFound, h)
+}
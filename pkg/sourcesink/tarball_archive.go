@@ -0,0 +1,379 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// tarballEntry records the location of a file within a tar archive.
+type tarballEntry struct {
+	offset   int64
+	size     int64
+	linkname string // Target of a link, or empty for a regular file.
+}
+
+// tarballArchive provides random access to the files held in an uncompressed
+// tar archive.
+type tarballArchive struct {
+	path    string
+	entries map[string]tarballEntry
+}
+
+// openTarballArchive scans the tar archive at p, recording the location of
+// each file that it contains.
+func openTarballArchive(p string) (*tarballArchive, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	a := tarballArchive{
+		path:    p,
+		entries: make(map[string]tarballEntry),
+	}
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(hdr.Name)
+
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			// As f is seekable, the tar reader leaves it positioned at the start
+			// of the file content.
+			offset, err := f.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			a.entries[name] = tarballEntry{offset: offset, size: hdr.Size}
+		case tar.TypeLink:
+			a.entries[name] = tarballEntry{linkname: path.Clean(hdr.Linkname)}
+		case tar.TypeSymlink:
+			a.entries[name] = tarballEntry{linkname: path.Join(path.Dir(name), hdr.Linkname)}
+		}
+	}
+
+	return &a, nil
+}
+
+// has returns true if the archive contains a file called name.
+func (a *tarballArchive) has(name string) bool {
+	_, ok := a.entries[name]
+	return ok
+}
+
+var (
+	errTarballFileNotFound = errors.New("file not found in tar archive")
+	errTarballLinkCycle    = errors.New("link cycle detected in tar archive")
+)
+
+// open returns an io.ReadCloser for the content of the file called name,
+// following any links.
+func (a *tarballArchive) open(name string) (io.ReadCloser, error) {
+	visited := make(map[string]bool)
+
+	e, ok := a.entries[name]
+	for ok && e.linkname != "" {
+		if visited[name] {
+			return nil, fmt.Errorf("%w: %v", errTarballLinkCycle, name)
+		}
+		visited[name] = true
+
+		name = e.linkname
+		e, ok = a.entries[name]
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", errTarballFileNotFound, name)
+	}
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.NewSectionReader(f, e.offset, e.size),
+		Closer: f,
+	}, nil
+}
+
+// bytes returns the content of the file called name.
+func (a *tarballArchive) bytes(name string) ([]byte, error) {
+	rc, err := a.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// blobPath returns the path of the blob with digest h in an OCI image layout.
+func blobPath(h v1.Hash) string {
+	return path.Join("blobs", h.Algorithm, h.Hex)
+}
+
+// blob returns an io.ReadCloser for the content of the blob with digest h, from
+// the OCI image layout held in the archive.
+func (a *tarballArchive) blob(h v1.Hash) (io.ReadCloser, error) {
+	return a.open(blobPath(h))
+}
+
+// blobBytes returns the content of the blob with digest h, from the OCI image
+// layout held in the archive.
+func (a *tarballArchive) blobBytes(h v1.Hash) ([]byte, error) {
+	return a.bytes(blobPath(h))
+}
+
+// rootIndex returns the index.json of the OCI image layout held in the archive.
+func (a *tarballArchive) rootIndex() (v1.ImageIndex, error) {
+	b, err := a.bytes("index.json")
+	if err != nil {
+		return nil, err
+	}
+
+	digest, size, err := v1.SHA256(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	return &tarballIndex{
+		a: a,
+		desc: v1.Descriptor{
+			MediaType: types.OCIImageIndex,
+			Size:      size,
+			Digest:    digest,
+		},
+		rawManifest: b,
+	}, nil
+}
+
+// image returns the image with descriptor desc, from the OCI image layout held
+// in the archive.
+func (a *tarballArchive) image(desc v1.Descriptor) (v1.Image, error) {
+	b, err := a.blobBytes(desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return partial.CompressedToImage(&tarballImage{
+		a:           a,
+		desc:        desc,
+		rawManifest: b,
+	})
+}
+
+// index returns the index with descriptor desc, from the OCI image layout held
+// in the archive.
+func (a *tarballArchive) index(desc v1.Descriptor) (v1.ImageIndex, error) {
+	b, err := a.blobBytes(desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tarballIndex{
+		a:           a,
+		desc:        desc,
+		rawManifest: b,
+	}, nil
+}
+
+var _ v1.ImageIndex = (*tarballIndex)(nil)
+
+// tarballIndex is an index held in an OCI image layout within a tar archive.
+type tarballIndex struct {
+	a           *tarballArchive
+	desc        v1.Descriptor
+	rawManifest []byte
+}
+
+// MediaType of this index's manifest.
+func (ix *tarballIndex) MediaType() (types.MediaType, error) {
+	return ix.desc.MediaType, nil
+}
+
+// Digest returns the sha256 of this index's manifest.
+func (ix *tarballIndex) Digest() (v1.Hash, error) {
+	return ix.desc.Digest, nil
+}
+
+// Size returns the size of the manifest.
+func (ix *tarballIndex) Size() (int64, error) {
+	return ix.desc.Size, nil
+}
+
+// IndexManifest returns this image index's manifest object.
+func (ix *tarballIndex) IndexManifest() (*v1.IndexManifest, error) {
+	var im v1.IndexManifest
+	err := json.Unmarshal(ix.rawManifest, &im)
+	return &im, err
+}
+
+// RawManifest returns the serialized bytes of IndexManifest().
+func (ix *tarballIndex) RawManifest() ([]byte, error) {
+	return ix.rawManifest, nil
+}
+
+// Descriptor returns a descriptor for the index.
+func (ix *tarballIndex) Descriptor() (*v1.Descriptor, error) {
+	return &ix.desc, nil
+}
+
+// Image returns a v1.Image that this ImageIndex references.
+func (ix *tarballIndex) Image(h v1.Hash) (v1.Image, error) {
+	desc, err := ix.findDescriptor(h)
+	if err != nil {
+		return nil, err
+	}
+
+	if mt := desc.MediaType; !mt.IsImage() {
+		return nil, fmt.Errorf("%w for %v: %v", ErrUnsupportedMediaType, h, mt)
+	}
+
+	return ix.a.image(*desc)
+}
+
+// ImageIndex returns a v1.ImageIndex that this ImageIndex references.
+func (ix *tarballIndex) ImageIndex(h v1.Hash) (v1.ImageIndex, error) {
+	desc, err := ix.findDescriptor(h)
+	if err != nil {
+		return nil, err
+	}
+
+	if mt := desc.MediaType; !mt.IsIndex() {
+		return nil, fmt.Errorf("%w for %v: %v", ErrUnsupportedMediaType, h, mt)
+	}
+
+	return ix.a.index(*desc)
+}
+
+// findDescriptor returns the first descriptor in the index with digest h.
+func (ix *tarballIndex) findDescriptor(h v1.Hash) (*v1.Descriptor, error) {
+	im, err := ix.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, desc := range im.Manifests {
+		if desc.Digest == h {
+			return &desc, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %v", ErrNoManifest, h)
+}
+
+var _ partial.CompressedImageCore = (*tarballImage)(nil)
+
+// tarballImage is an image held in an OCI image layout within a tar archive.
+type tarballImage struct {
+	a           *tarballArchive
+	desc        v1.Descriptor
+	rawManifest []byte
+}
+
+// RawConfigFile returns the serialized bytes of the image's config file.
+func (im *tarballImage) RawConfigFile() ([]byte, error) {
+	m, err := v1.ParseManifest(bytes.NewReader(im.rawManifest))
+	if err != nil {
+		return nil, err
+	}
+
+	return im.a.blobBytes(m.Config.Digest)
+}
+
+// MediaType of this image's manifest.
+func (im *tarballImage) MediaType() (types.MediaType, error) {
+	return im.desc.MediaType, nil
+}
+
+// RawManifest returns the serialized bytes of the image's manifest.
+func (im *tarballImage) RawManifest() ([]byte, error) {
+	return im.rawManifest, nil
+}
+
+// Descriptor returns a descriptor for the image.
+func (im *tarballImage) Descriptor() (*v1.Descriptor, error) {
+	return &im.desc, nil
+}
+
+var errLayerNotFoundInImage = errors.New("layer not found in image")
+
+// LayerByDigest returns a layer for interacting with a particular layer of the
+// image, looking it up by "digest" (the compressed hash).
+func (im *tarballImage) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	m, err := v1.ParseManifest(bytes.NewReader(im.rawManifest))
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Config.Digest == h {
+		return &tarballLayer{a: im.a, desc: m.Config}, nil
+	}
+
+	for _, desc := range m.Layers {
+		if desc.Digest == h {
+			return &tarballLayer{a: im.a, desc: desc}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %v", errLayerNotFoundInImage, h)
+}
+
+var _ partial.CompressedLayer = (*tarballLayer)(nil)
+
+// tarballLayer is a layer held in an OCI image layout within a tar archive.
+type tarballLayer struct {
+	a    *tarballArchive
+	desc v1.Descriptor
+}
+
+// Digest returns the Hash of the compressed layer.
+func (l *tarballLayer) Digest() (v1.Hash, error) {
+	return l.desc.Digest, nil
+}
+
+// Compressed returns an io.ReadCloser for the compressed layer contents.
+func (l *tarballLayer) Compressed() (io.ReadCloser, error) {
+	return l.a.blob(l.desc.Digest)
+}
+
+// Size returns the compressed size of the Layer.
+func (l *tarballLayer) Size() (int64, error) {
+	return l.desc.Size, nil
+}
+
+// MediaType returns the media type of the Layer.
+func (l *tarballLayer) MediaType() (types.MediaType, error) {
+	return l.desc.MediaType, nil
+}
+
+// Descriptor returns a descriptor for the layer.
+func (l *tarballLayer) Descriptor() (*v1.Descriptor, error) {
+	return &l.desc, nil
+}
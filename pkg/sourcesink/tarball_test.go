@@ -0,0 +1,616 @@
+// Copyright 2026 Sylabs Inc. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcesink
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sebdah/goldie/v2"
+	"github.com/sylabs/oci-tools/pkg/ociplatform"
+)
+
+// tarLayout writes the content of the OCI image layout at dir to a tar
+// archive, returning its path.
+func tarLayout(t *testing.T, dir string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "oci.tar")
+
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return writeTarballFile(tw, filepath.ToSlash(rel), b)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// dockerArchive writes a docker archive holding an image with two tags, and an
+// untagged image, returning its path.
+func dockerArchive(t *testing.T) string {
+	t.Helper()
+
+	img := corpus.Image(t, "hello-world-docker-v2-manifest")
+
+	untagged := corpus.Image(t, "many-layers")
+	h, err := untagged.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	untaggedRef, err := name.NewDigest("many-layers@" + h.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(t.TempDir(), "docker.tar")
+	err = tarball.MultiRefWriteToFile(p, map[name.Reference]v1.Image{
+		name.MustParseReference("hello-world:latest"): img,
+		name.MustParseReference("hello-world:arm64"):  img,
+		untaggedRef: untagged,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// containerdArchive writes an OCI archive, as created by `docker save`, holding
+// an image with an io.containerd.image.name annotation, returning its path.
+func containerdArchive(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.AppendImage(corpus.Image(t, "hello-world-docker-v2-manifest"),
+		layout.WithAnnotations(map[string]string{
+			annotationContainerdImageName: "docker.io/library/hello-world:latest",
+			imagespec.AnnotationRefName:   "latest",
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tarLayout(t, dir)
+}
+
+func TestTarballFromPath(t *testing.T) {
+	notTar := filepath.Join(t.TempDir(), "not.tar")
+	if err := os.WriteFile(notTar, []byte("not a tar archive"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		opts    []Option
+		wantErr bool
+	}{
+		{
+			name: "OCI",
+			src:  tarLayout(t, corpus.ImagePath("hello-world-docker-v2-manifest")),
+		},
+		{
+			name: "Docker",
+			src:  dockerArchive(t),
+		},
+		{
+			name: "WithInstrumentationLogs",
+			src:  tarLayout(t, corpus.ImagePath("hello-world-docker-v2-manifest")),
+			opts: []Option{OptWithInstrumentationLogs(slog.Default())},
+		},
+		{
+			name:    "EmptyArchive",
+			src:     tarLayout(t, t.TempDir()),
+			wantErr: true,
+		},
+		{
+			name:    "NotArchive",
+			src:     notTar,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := TarballFromPath(tt.src, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TarballFromPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTarballGet(t *testing.T) {
+	imgDigest := v1.Hash{Algorithm: "sha256", Hex: "432f982638b3aefab73cc58ab28f5c16e96fdb504e8c134fc58dff4bae8bf338"}
+	idxDigest := v1.Hash{Algorithm: "sha256", Hex: "00e1ee7c898a2c393ea2fe7680938f8dcbe55e51fbf08032cf37326a677f92ed"}
+
+	imgArchive := tarLayout(t, corpus.ImagePath("hello-world-docker-v2-manifest"))
+	idxArchive := tarLayout(t, corpus.ImagePath("hello-world-docker-v2-manifest-list"))
+	dockerArchive := dockerArchive(t)
+
+	tests := []struct {
+		name    string
+		src     string
+		opts    []GetOpt
+		wantErr error
+	}{
+		{
+			name:    "ImageDefaults",
+			src:     imgArchive,
+			opts:    []GetOpt{},
+			wantErr: nil,
+		},
+		{
+			name:    "ImagePlatform",
+			src:     imgArchive,
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "arm64"})},
+			wantErr: nil,
+		},
+		{
+			name:    "ImageBadPlatform",
+			src:     imgArchive,
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "m68k"})},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "ImageDigest",
+			src:     imgArchive,
+			opts:    []GetOpt{GetWithDigest(imgDigest)},
+			wantErr: nil,
+		},
+		{
+			name:    "ImageBadDigest",
+			src:     imgArchive,
+			opts:    []GetOpt{GetWithDigest(v1.Hash{})},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "IndexDefaults",
+			src:     idxArchive,
+			opts:    []GetOpt{},
+			wantErr: nil,
+		},
+		{
+			name:    "IndexPlatform",
+			src:     idxArchive,
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "arm64", Variant: "v8"})},
+			wantErr: nil,
+		},
+		{
+			name:    "IndexBadPlatform",
+			src:     idxArchive,
+			opts:    []GetOpt{GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "m68k"})},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name:    "IndexDigest",
+			src:     idxArchive,
+			opts:    []GetOpt{GetWithDigest(idxDigest)},
+			wantErr: nil,
+		},
+		{
+			name:    "ContainerdReference",
+			src:     containerdArchive(t),
+			opts:    []GetOpt{GetWithReference(name.MustParseReference("hello-world:latest"))},
+			wantErr: nil,
+		},
+		{
+			name:    "DockerDefaults",
+			src:     dockerArchive,
+			opts:    []GetOpt{},
+			wantErr: nil,
+		},
+		{
+			name:    "DockerReference",
+			src:     dockerArchive,
+			opts:    []GetOpt{GetWithReference(name.MustParseReference("hello-world:latest"))},
+			wantErr: nil,
+		},
+		{
+			name:    "DockerOtherReference",
+			src:     dockerArchive,
+			opts:    []GetOpt{GetWithReference(name.MustParseReference("hello-world:arm64"))},
+			wantErr: nil,
+		},
+		{
+			name:    "DockerBadReference",
+			src:     dockerArchive,
+			opts:    []GetOpt{GetWithReference(name.MustParseReference("hello-world:missing"))},
+			wantErr: ErrNoManifest,
+		},
+		{
+			name: "DockerReferencePlatform",
+			src:  dockerArchive,
+			opts: []GetOpt{
+				GetWithReference(name.MustParseReference("hello-world:latest")),
+				GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "arm64"}),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "DockerReferenceBadPlatform",
+			src:  dockerArchive,
+			opts: []GetOpt{
+				GetWithReference(name.MustParseReference("hello-world:latest")),
+				GetWithPlatform(v1.Platform{OS: "Linux", Architecture: "m68k"}),
+			},
+			wantErr: ErrNoManifest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := TarballFromPath(tt.src)
+			if err != nil {
+				t.Fatalf("TarballFromPath() error = %v", err)
+			}
+			d, err := s.Get(t.Context(), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			mf, err := d.RawManifest()
+			if err != nil {
+				t.Fatalf("RawManifest() error = %v", err)
+			}
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, mf)
+		})
+	}
+}
+
+func TestTarballDescriptorImage(t *testing.T) {
+	tests := []struct {
+		name         string
+		src          string
+		opts         []GetOpt
+		wantPlatform v1.Platform
+	}{
+		{
+			name:         "FromImage",
+			src:          tarLayout(t, corpus.ImagePath("hello-world-docker-v2-manifest")),
+			wantPlatform: v1.Platform{OS: "Linux", Architecture: "arm64", Variant: "v8"},
+		},
+		{
+			name:         "FromIndex",
+			src:          tarLayout(t, corpus.ImagePath("hello-world-docker-v2-manifest-list")),
+			wantPlatform: *ociplatform.DefaultPlatform(),
+		},
+		{
+			name:         "FromDocker",
+			src:          dockerArchive(t),
+			opts:         []GetOpt{GetWithReference(name.MustParseReference("hello-world:latest"))},
+			wantPlatform: v1.Platform{OS: "Linux", Architecture: "arm64", Variant: "v8"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := TarballFromPath(tt.src)
+			if err != nil {
+				t.Fatalf("TarballFromPath() error = %v", err)
+			}
+			d, err := s.Get(t.Context(), tt.opts...)
+			if err != nil {
+				t.Fatalf(".Get() error = %v", err)
+			}
+
+			img, err := d.Image()
+			if err != nil {
+				t.Fatalf(".Image() error = %v", err)
+			}
+
+			if err := ociplatform.EnsureImageSatisfies(img, tt.wantPlatform); err != nil {
+				t.Fatalf("Image does not satisfy expected platform %v", tt.wantPlatform)
+			}
+		})
+	}
+}
+
+func TestTarballDescriptorImageIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr error
+	}{
+		{
+			name:    "FromImage",
+			src:     tarLayout(t, corpus.ImagePath("hello-world-docker-v2-manifest")),
+			wantErr: ErrUnsupportedMediaType,
+		},
+		{
+			name:    "FromIndex",
+			src:     tarLayout(t, corpus.ImagePath("hello-world-docker-v2-manifest-list")),
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := TarballFromPath(tt.src)
+			if err != nil {
+				t.Fatalf("TarballFromPath() error = %v", err)
+			}
+			d, err := s.Get(t.Context())
+			if err != nil {
+				t.Fatalf(".Get() error = %v", err)
+			}
+
+			_, err = d.ImageIndex()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf(".ImageIndex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTarballWrite(t *testing.T) {
+	ref := name.MustParseReference("my:image", name.WithDefaultRegistry(""))
+
+	tests := []struct {
+		name   string
+		writes []Writable
+		opts   []WriteOpt
+	}{
+		{
+			name:   "ImageDefaults",
+			writes: []Writable{corpus.Image(t, "hello-world-docker-v2-manifest")},
+			opts:   []WriteOpt{},
+		},
+		{
+			name:   "ImageWithReference",
+			writes: []Writable{corpus.Image(t, "hello-world-docker-v2-manifest")},
+			opts:   []WriteOpt{WriteWithReference(ref)},
+		},
+		{
+			name:   "IndexDefaults",
+			writes: []Writable{corpus.ImageIndex(t, "hello-world-docker-v2-manifest-list")},
+			opts:   []WriteOpt{},
+		},
+		{
+			name:   "IndexWithReference",
+			writes: []Writable{corpus.ImageIndex(t, "hello-world-docker-v2-manifest-list")},
+			opts:   []WriteOpt{WriteWithReference(ref)},
+		},
+		{
+			name: "MoveReference",
+			writes: []Writable{
+				corpus.Image(t, "hello-world-docker-v2-manifest"),
+				corpus.ImageIndex(t, "hello-world-docker-v2-manifest-list"),
+			},
+			opts: []WriteOpt{WriteWithReference(ref)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "oci.tar")
+
+			s, err := TarballEmpty(path)
+			if err != nil {
+				t.Fatalf("TarballEmpty() error = %v", err)
+			}
+			if err := os.Chmod(path, 0o640); err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range tt.writes {
+				if err := s.Write(t.Context(), w, tt.opts...); err != nil {
+					t.Fatalf(".Write() error = %v", err)
+				}
+			}
+
+			// The permissions of the archive must be preserved when it is rewritten.
+			fi, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := fi.Mode().Perm(), fs.FileMode(0o640); got != want {
+				t.Errorf("got mode %v, want %v", got, want)
+			}
+
+			// Written manifests must be retrievable from the rewritten archive.
+			s, err = TarballFromPath(path)
+			if err != nil {
+				t.Fatalf("TarballFromPath() error = %v", err)
+			}
+			for _, w := range tt.writes {
+				want, err := w.RawManifest()
+				if err != nil {
+					t.Fatal(err)
+				}
+				h, _, err := v1.SHA256(bytes.NewReader(want))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				rc, err := s.Blob(t.Context(), GetWithDigest(h))
+				if err != nil {
+					t.Fatalf(".Blob() error = %v", err)
+				}
+				got, err := io.ReadAll(rc)
+				rc.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(got, want) {
+					t.Errorf("got manifest %s, want %s", got, want)
+				}
+			}
+
+			a, err := openTarballArchive(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			index, err := a.bytes("index.json")
+			if err != nil {
+				t.Fatalf("while reading index.json: %v", err)
+			}
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, index)
+		})
+	}
+}
+
+func TestTarballWriteDocker(t *testing.T) {
+	s, err := TarballFromPath(dockerArchive(t))
+	if err != nil {
+		t.Fatalf("TarballFromPath() error = %v", err)
+	}
+
+	err = s.Write(t.Context(), corpus.Image(t, "hello-world-docker-v2-manifest"))
+	if !errors.Is(err, errTarballWriteDocker) {
+		t.Fatalf(".Write() error = %v, wantErr %v", err, errTarballWriteDocker)
+	}
+}
+
+func TestTarballBlob(t *testing.T) {
+	imgArchive := tarLayout(t, corpus.ImagePath("hello-world-docker-v2-manifest"))
+	dockerArchive := dockerArchive(t)
+
+	tests := []struct {
+		name    string
+		src     string
+		opts    []GetOpt
+		wantErr error
+	}{
+		{
+			name: "ImageConfig",
+			src:  imgArchive,
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "46331d942d6350436f64e614d75725f6de3bb5c63e266e236e04389820a234c4"})},
+			wantErr: nil,
+		},
+		{
+			name: "ImageLayer",
+			src:  imgArchive,
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff915842962cb813f32062d3bbdd35c750dd7d01"})},
+			wantErr: nil,
+		},
+		{
+			name: "DockerConfig",
+			src:  dockerArchive,
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "46331d942d6350436f64e614d75725f6de3bb5c63e266e236e04389820a234c4"})},
+			wantErr: nil,
+		},
+		{
+			name: "DockerLayer",
+			src:  dockerArchive,
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff915842962cb813f32062d3bbdd35c750dd7d01"})},
+			wantErr: nil,
+		},
+		{
+			name: "ErrNotFound",
+			src:  imgArchive,
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "0000000000000000000000000000000000000000000000000000000000000000"})},
+			wantErr: errTarballFileNotFound,
+		},
+		{
+			name: "ErrDockerNotFound",
+			src:  dockerArchive,
+			opts: []GetOpt{GetWithDigest(
+				v1.Hash{Algorithm: "sha256", Hex: "0000000000000000000000000000000000000000000000000000000000000000"})},
+			wantErr: errTarballFileNotFound,
+		},
+		{
+			name:    "ErrNoDigest",
+			src:     imgArchive,
+			opts:    []GetOpt{},
+			wantErr: errBlobNoDigest,
+		},
+		{
+			name: "ErrPlatform",
+			src:  imgArchive,
+			opts: []GetOpt{
+				GetWithDigest(v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff95842962cb813f32062d3bbdd35c750dd7d01"}),
+				GetWithPlatform(*ociplatform.DefaultPlatform()),
+			},
+			wantErr: errBlobPlatform,
+		},
+		{
+			name: "ErrReference",
+			src:  imgArchive,
+			opts: []GetOpt{
+				GetWithDigest(v1.Hash{Algorithm: "sha256", Hex: "7050e35b49f5e348c4809f5eff95842962cb813f32062d3bbdd35c750dd7d01"}),
+				GetWithReference(name.MustParseReference("test")),
+			},
+			wantErr: errBlobReference,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := TarballFromPath(tt.src)
+			if err != nil {
+				t.Fatalf("TarballFromPath() error = %v", err)
+			}
+			rc, err := s.Blob(t.Context(), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Blob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+			defer rc.Close()
+
+			b, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("ReadAll error: %v", err)
+			}
+
+			g := goldie.New(t, goldie.WithTestNameForDir(true))
+			g.Assert(t, tt.name, b)
+		})
+	}
+}